@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	appConstants "dessert-ordering-go-system/internal/app_constants"
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	responses "dessert-ordering-go-system/internal/response"
+	models "dessert-ordering-go-system/models"
+	services "dessert-ordering-go-system/services"
+)
+
+// renderAdminProducts renders the admin catalog page with the given errors.
+func (h *WebHandler) renderAdminProducts(w http.ResponseWriter, r *http.Request, statusCode int, form *services.ProductForm, errs ...string) {
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+
+	data, templateDataErr := h.Services.AdminProductsTemplateData.GetAdminProductsTemplateContent(
+		r.Context(),
+		h.Services.AdminProductsTemplateData.WithCsrfToken(csrfToken),
+		h.Services.AdminProductsTemplateData.WithErrors(errs),
+		h.Services.AdminProductsTemplateData.WithForm(form),
+	)
+	if templateDataErr != nil {
+		h.Logger.ErrorContext(r.Context(), "renderAdminProducts - GetAdminProductsTemplateContent", "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+		return
+	}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	h.RenderHtmlTemplate(w, "admin_products.html", data, statusCode)
+}
+
+// writeAdminProductError maps catalog errors onto status codes and answers in
+// JSON or by re-rendering the admin page.
+func (h *WebHandler) writeAdminProductError(w http.ResponseWriter, r *http.Request, err error, form *services.ProductForm) {
+	statusCode := serverErrorStatus(err)
+	message := err.Error()
+
+	if _, ok := err.(*appErrors.NotFoundError); ok {
+		statusCode = http.StatusNotFound
+	} else if errors.Is(err, models.ErrDuplicateRecord) {
+		statusCode = http.StatusConflict
+		message = "a product with these details already exists"
+	} else if errors.Is(err, models.ErrInvalidImageOrder) || errors.Is(err, services.ErrInvalidPrice) {
+		statusCode = http.StatusBadRequest
+	} else {
+		h.Logger.ErrorContext(r.Context(), "Admin catalog request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		message = "An internal error occurred while updating the catalog."
+	}
+
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		response := responses.NewErrorJsonResponse(message)
+		responses.WriteJsonResponse(w, statusCode, response)
+		return
+	}
+	h.renderAdminProducts(w, r, statusCode, form, message)
+}
+
+// writeAdminValidationErrors answers a request whose body failed validation.
+func (h *WebHandler) writeAdminValidationErrors(w http.ResponseWriter, r *http.Request, validationErrors map[string]string, form *services.ProductForm) {
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+		responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	var errs []string
+	for field, msg := range validationErrors {
+		errs = append(errs, fmt.Sprintf("%s: %s", field, msg))
+	}
+	h.renderAdminProducts(w, r, http.StatusBadRequest, form, errs...)
+}
+
+// writeAdminSuccess answers a successful catalog change.
+func (h *WebHandler) writeAdminSuccess(w http.ResponseWriter, r *http.Request, statusCode int, message string, data any) {
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		response := responses.NewSuccessJsonDataResponse(message, data)
+		responses.WriteJsonResponse(w, statusCode, response)
+		return
+	}
+	http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
+}
+
+// adminPathID reads a numeric path parameter, answering 400 when it is invalid.
+func (h *WebHandler) adminPathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, name))
+	if err != nil || id < 1 {
+		message := fmt.Sprintf("Invalid %s", strings.ReplaceAll(name, "_", " "))
+		if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			h.renderAdminProducts(w, r, http.StatusBadRequest, nil, message)
+		}
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeProductForm reads a ProductForm from a JSON body or a form post.
+func decodeProductForm(w http.ResponseWriter, r *http.Request, form *services.ProductForm) (int, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return JsonBodyDecoder(w, r, form)
+	}
+
+	readProductFormValues(r, form)
+	return http.StatusOK, nil
+}
+
+// decodeProductUpdateForm reads a ProductUpdateForm from a JSON body or a form post.
+func decodeProductUpdateForm(w http.ResponseWriter, r *http.Request, form *services.ProductUpdateForm) (int, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return JsonBodyDecoder(w, r, form)
+	}
+
+	readProductFormValues(r, &form.ProductForm)
+	form.ExpectedStock = formInt(r, "expectedStock")
+	return http.StatusOK, nil
+}
+
+func readProductFormValues(r *http.Request, form *services.ProductForm) {
+	form.Title = r.FormValue("title")
+	form.Category = r.FormValue("category")
+	form.Description = r.FormValue("description")
+	form.Price = r.FormValue("price")
+	form.Thumbnail = r.FormValue("thumbnail")
+	form.Stock = formInt(r, "stock")
+}
+
+// formInt reads a whole number form value, or nil when it is missing or not a
+// number, which validation then reports as required.
+func formInt(r *http.Request, key string) *int {
+	value, err := strconv.Atoi(r.FormValue(key))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func (h *WebHandler) GetAdminProductsHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		products, err := h.Services.Product.GetAdminProducts(r.Context())
+		if err != nil {
+			h.writeAdminProductError(w, r, err, nil)
+			return
+		}
+		response := responses.NewSuccessJsonDataResponse("Fetched Products", products)
+		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
+		return
+	}
+
+	h.renderAdminProducts(w, r, http.StatusOK, nil)
+}
+
+func (h *WebHandler) GetAdminProductHandler(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
+		return
+	}
+
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	product, err := h.Services.Product.GetAdminProduct(r.Context(), productID)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+	response := responses.NewSuccessJsonDataResponse("Fetched Product", product)
+	responses.WriteJsonResponse(w, http.StatusOK, response)
+}
+
+func (h *WebHandler) CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+	var form services.ProductForm
+
+	errStatusCode, err := decodeProductForm(w, r, &form)
+	if err != nil {
+		response := responses.NewErrorJsonResponse(err.Error())
+		responses.WriteJsonResponse(w, errStatusCode, response)
+		return
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(form)
+	if validationErrors != nil {
+		h.writeAdminValidationErrors(w, r, validationErrors, &form)
+		return
+	}
+
+	input, err := form.ToInput()
+	if err != nil {
+		h.writeAdminProductError(w, r, err, &form)
+		return
+	}
+
+	product, err := h.Services.Product.CreateProduct(r.Context(), input)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, &form)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusCreated, "Product Created", product)
+}
+
+func (h *WebHandler) UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var form services.ProductUpdateForm
+
+	errStatusCode, err := decodeProductUpdateForm(w, r, &form)
+	if err != nil {
+		response := responses.NewErrorJsonResponse(err.Error())
+		responses.WriteJsonResponse(w, errStatusCode, response)
+		return
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(form)
+	if validationErrors != nil {
+		h.writeAdminValidationErrors(w, r, validationErrors, nil)
+		return
+	}
+
+	input, err := form.ToInput()
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	product, err := h.Services.Product.UpdateProduct(r.Context(), productID, input)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusOK, "Product Updated", product)
+}
+
+func (h *WebHandler) ArchiveProductHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	product, err := h.Services.Product.ArchiveProduct(r.Context(), productID)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusOK, "Product Archived", product)
+}
+
+func (h *WebHandler) RestoreProductHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	product, err := h.Services.Product.RestoreProduct(r.Context(), productID)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusOK, "Product Restored", product)
+}
+
+func (h *WebHandler) AddProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var form services.ProductImageForm
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &form)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		form.Image = r.FormValue("image")
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(form)
+	if validationErrors != nil {
+		h.writeAdminValidationErrors(w, r, validationErrors, nil)
+		return
+	}
+
+	image, err := h.Services.Product.AddProductImage(r.Context(), productID, form.Image)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusCreated, "Product Image Added", image)
+}
+
+func (h *WebHandler) ReorderProductImagesHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var form services.ProductImageOrderForm
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &form)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		// Html forms send the new order as a comma separated list, e.g. "3,1,2"
+		for _, value := range strings.Split(r.FormValue("imageIds"), ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			imageID, err := strconv.Atoi(value)
+			if err != nil {
+				h.writeAdminValidationErrors(w, r, map[string]string{"imageids": fmt.Sprintf("invalid image ID %q", value)}, nil)
+				return
+			}
+			form.ImageIDs = append(form.ImageIDs, imageID)
+		}
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(form)
+	if validationErrors != nil {
+		h.writeAdminValidationErrors(w, r, validationErrors, nil)
+		return
+	}
+
+	images, err := h.Services.Product.ReorderProductImages(r.Context(), productID, form.ImageIDs)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusOK, "Product Images Reordered", images)
+}
+
+func (h *WebHandler) RemoveProductImageHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.adminPathID(w, r, "id")
+	if !ok {
+		return
+	}
+	imageID, ok := h.adminPathID(w, r, "image_id")
+	if !ok {
+		return
+	}
+
+	err := h.Services.Product.RemoveProductImage(r.Context(), productID, imageID)
+	if err != nil {
+		h.writeAdminProductError(w, r, err, nil)
+		return
+	}
+
+	h.writeAdminSuccess(w, r, http.StatusOK, "Product Image Removed", nil)
+}
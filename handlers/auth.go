@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	appConstants "dessert-ordering-go-system/internal/app_constants"
+	logging "dessert-ordering-go-system/internal/logging"
 	responses "dessert-ordering-go-system/internal/response"
 	utils "dessert-ordering-go-system/internal/utils"
+	models "dessert-ordering-go-system/models"
 	services "dessert-ordering-go-system/services"
 )
 
@@ -19,14 +22,18 @@ func (h *WebHandler) GetLoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	data, templateDataErr := h.Services.LoginTemplateData.GetLoginTemplateContent(h.Services.LoginTemplateData.WithCsrfToken(csrfToken))
 	if templateDataErr != nil {
-		h.Loggers.Error.Printf("ERROR: GetLoginHandler - GetLoginTemplateContent: %v", templateDataErr)
-		http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+		h.Logger.ErrorContext(r.Context(), "GetLoginHandler - GetLoginTemplateContent", "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 		return
 	}
 	flashError := h.Session.PopFlashError(r.Context())
 	if flashError != "" {
 		data.Errors = append(data.Errors, flashError)
 	}
+	flashMessage := h.Session.PopFlashMessage(r.Context())
+	if flashMessage != "" {
+		data.Messages = append(data.Messages, flashMessage)
+	}
 	h.RenderHtmlTemplate(w, "login.html", data, http.StatusOK)
 }
 
@@ -58,8 +65,8 @@ func (h *WebHandler) PostLoginHandler(w http.ResponseWriter, r *http.Request) {
 		} else {
 			data, templateDataErr := h.Services.LoginTemplateData.GetLoginTemplateContent(h.Services.LoginTemplateData.WithCsrfToken(csrfToken))
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: PostLoginHandler - GetLoginTemplateContent on validation error: %v", templateDataErr)
-				http.Error(w, "Failed to reload login page after validation error", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "PostLoginHandler - GetLoginTemplateContent on validation error", "error", templateDataErr)
+				http.Error(w, "Failed to reload login page after validation error", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Form = &formData
@@ -72,31 +79,46 @@ func (h *WebHandler) PostLoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userData, err := h.Services.Auth.Authenticate(formData.Contact, formData.Password)
+	userData, err := h.Services.Auth.Authenticate(r.Context(), formData.Contact, formData.Password)
 	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, models.ErrQueryTimeout) {
+			statusCode = http.StatusGatewayTimeout
+		}
+		// Only wrong contacts or passwords, not database failures
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			h.Metrics.FailedLogins.Inc()
+		}
+
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
-			responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
+			responses.WriteJsonResponse(w, statusCode, response)
 		} else {
 			data, templateDataErr := h.Services.LoginTemplateData.GetLoginTemplateContent(h.Services.LoginTemplateData.WithCsrfToken(csrfToken))
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: PostLoginHandler - GetLoginTemplateContent: %v", templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "PostLoginHandler - GetLoginTemplateContent", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Form = &formData
 			data.Errors = append(data.Errors, err.Error())
-			h.RenderHtmlTemplate(w, "login.html", data, http.StatusUnauthorized)
+			h.RenderHtmlTemplate(w, "login.html", data, statusCode)
 		}
 		return
 	}
 
 	// Log in the user
 	h.Session.SetAuthUserID(r.Context(), userData.ID) // Session Auth
+	h.Session.SetAuthUserRole(r.Context(), userData.Role)
+	h.Session.SetAuthLoggedInAt(r.Context(), time.Now())
+	logging.SetUserID(r.Context(), userData.ID)
+
+	// Carry over anything the visitor put in their cart before logging in
+	h.mergeGuestCart(r, userData.ID)
 
-	token, err := h.Services.Auth.GenerateAuthToken(userData.ID, userData.Username, userData.Email)
+	token, err := h.Services.Auth.GenerateAuthToken(userData.ID, userData.Username, userData.Email, userData.Role)
 	if err != nil {
-		h.Loggers.Error.Printf("ERROR: PostLoginHandler - h.Services.Auth.GenerateAuthToken: %v", err)
+		h.Logger.ErrorContext(r.Context(), "PostLoginHandler - h.Services.Auth.GenerateAuthToken", "error", err)
 		h.Session.SetFlashError(r.Context(), err.Error())
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
@@ -110,18 +132,18 @@ func (h *WebHandler) PostLoginHandler(w http.ResponseWriter, r *http.Request) {
 	authData := h.Services.Auth.CreateAuthData(*userData, token)
 
 	if strings.HasPrefix(acceptType, "application/json") {
+		// API clients renew their token with a refresh token instead of the password
+		refreshToken, err := h.Services.Auth.IssueRefreshToken(r.Context(), userData.ID)
+		if err != nil {
+			h.Logger.ErrorContext(r.Context(), "PostLoginHandler - h.Services.Auth.IssueRefreshToken", "error", err)
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
+			return
+		}
+		authData.RefreshToken = refreshToken
+
 		response := responses.NewSuccessJsonDataResponse("Log in successful", authData)
-		secureCookies, _ := appConstants.GetSecureCookies()
-		cookie := &http.Cookie{
-			Name:     appConstants.Jwt_Name,
-			Value:    authData.Token,
-			Expires:  time.Now().Add(h.Services.Auth.GetTokenExpiration()),
-			HttpOnly: true,
-			Secure:   secureCookies,
-			SameSite: http.SameSiteLaxMode,
-			Path:     "/",
-		}
-		http.SetCookie(w, cookie)
+		http.SetCookie(w, h.newJwtCookie(authData.Token))
 		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
 		return
 	}
@@ -129,6 +151,64 @@ func (h *WebHandler) PostLoginHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// newJwtCookie returns the HttpOnly cookie that carries token for browsers.
+func (h *WebHandler) newJwtCookie(token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     appConstants.Jwt_Name,
+		Value:    token,
+		Expires:  time.Now().Add(h.Services.Auth.GetTokenExpiration()),
+		HttpOnly: true,
+		Secure:   h.Config.Session.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
+	}
+}
+
+// ****** Refresh Token Handlers *******
+
+// RefreshTokenHandler trades a refresh token for a new JWT and a new refresh
+// token. It serves API clients, which send the refresh token in a JSON body,
+// so it needs neither a session nor a CSRF token.
+func (h *WebHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var formData services.RefreshForm
+
+	errStatusCode, err := JsonBodyDecoder(w, r, &formData)
+	if err != nil {
+		response := responses.NewErrorJsonResponse(err.Error())
+		responses.WriteJsonResponse(w, errStatusCode, response)
+		return
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(formData)
+	if validationErrors != nil {
+		response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+		responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	authData, err := h.Services.Auth.RefreshAuthToken(r.Context(), formData.RefreshToken)
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		switch {
+		case errors.Is(err, models.ErrInvalidRefreshToken), errors.Is(err, models.ErrRefreshTokenReused), errors.Is(err, models.ErrUserNotFound):
+		default:
+			h.Logger.ErrorContext(r.Context(), "RefreshTokenHandler - h.Services.Auth.RefreshAuthToken", "error", err)
+			statusCode = serverErrorStatus(err)
+		}
+
+		response := responses.NewErrorJsonResponse(err.Error())
+		responses.WriteJsonResponse(w, statusCode, response)
+		return
+	}
+
+	logging.SetUserID(r.Context(), authData.User.ID)
+
+	http.SetCookie(w, h.newJwtCookie(authData.Token))
+	response := responses.NewSuccessJsonDataResponse("Token refreshed", authData)
+	responses.WriteJsonResponse(w, http.StatusOK, response)
+}
+
 // ****** Register Handlers *******
 
 func (h *WebHandler) GetRegisterHandler(w http.ResponseWriter, r *http.Request) {
@@ -136,8 +216,8 @@ func (h *WebHandler) GetRegisterHandler(w http.ResponseWriter, r *http.Request)
 
 	data, templateDataErr := h.Services.RegisterTemplateData.GetRegisterTemplateContent(h.Services.RegisterTemplateData.WithCsrfToken(csrfToken))
 	if templateDataErr != nil {
-		h.Loggers.Error.Printf("ERROR: GetRegisterHandler - GetRegisterTemplateContent: %v", templateDataErr)
-		http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+		h.Logger.ErrorContext(r.Context(), "GetRegisterHandler - GetRegisterTemplateContent", "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 		return
 	}
 	flashError := h.Session.PopFlashError(r.Context())
@@ -177,8 +257,8 @@ func (h *WebHandler) PostRegisterHandler(w http.ResponseWriter, r *http.Request)
 		} else {
 			data, templateDataErr := h.Services.RegisterTemplateData.GetRegisterTemplateContent(h.Services.RegisterTemplateData.WithCsrfToken(csrfToken))
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: PostRegisterHandler - GetRegisterTemplateContent on validation error: %v", templateDataErr)
-				http.Error(w, "Failed to reload register page after validation error", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "PostRegisterHandler - GetRegisterTemplateContent on validation error", "error", templateDataErr)
+				http.Error(w, "Failed to reload register page after validation error", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Form = &formData
@@ -191,47 +271,92 @@ func (h *WebHandler) PostRegisterHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.Services.Auth.RegisterUser(formData.Username, formData.Email, formData.Password)
+	err := h.Services.Auth.RegisterUser(r.Context(), formData.Username, formData.Email, formData.Password)
 	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, models.ErrQueryTimeout) {
+			statusCode = http.StatusGatewayTimeout
+		}
+
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
-			responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
+			responses.WriteJsonResponse(w, statusCode, response)
 		} else {
 			data, templateDataErr := h.Services.RegisterTemplateData.GetRegisterTemplateContent(h.Services.RegisterTemplateData.WithCsrfToken(csrfToken))
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: PostRegisterHandler - GetRegisterTemplateContent: %v", templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "PostRegisterHandler - GetRegisterTemplateContent", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Form = &formData
 			data.Errors = append(data.Errors, err.Error())
-			h.RenderHtmlTemplate(w, "register.html", data, http.StatusUnauthorized)
+			h.RenderHtmlTemplate(w, "register.html", data, statusCode)
 		}
 		return
 	}
 
+	// The account exists either way: a failed email can be sent again from the
+	// home page once the user logs in
+	if err := h.Services.EmailVerification.SendVerificationEmail(r.Context(), formData.Email); err != nil {
+		h.Logger.ErrorContext(r.Context(), "PostRegisterHandler - h.Services.EmailVerification.SendVerificationEmail", "error", err)
+	}
+
 	if strings.HasPrefix(acceptType, "application/json") {
-		response := responses.NewSuccessJsonResponse("Registration successful")
+		response := responses.NewSuccessJsonResponse("Registration successful, open the link we emailed you to verify your address")
 		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
 		return
 	}
 
+	h.Session.SetFlashMessage(r.Context(), "Registration successful, open the link we emailed you to verify your address")
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
 // ****** Logout Handlers *******
+
+// LogoutHandler ends the session and revokes the JWT of the request. API
+// clients also send {"refreshToken"} so that the refresh token of their login
+// stops working too.
 func (h *WebHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	acceptType := r.Header.Get("Accept")
 
-	h.Session.Destroy(r.Context())
+	var formData services.LogoutForm
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") && r.ContentLength != 0 {
+		errStatusCode, err := JsonBodyDecoder(w, r, &formData)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	}
 
-	newCsrfToken, err := utils.GenerateRandomString(32)
-	if err != nil {
-		h.Loggers.Error.Printf("ERROR: WebHandler.LogoutHandler - utils.GenerateRandomString: %v", err)
-	} else {
-		h.Session.SetCsrfToken(r.Context(), newCsrfToken)
+	// A refresh token would otherwise keep minting JWTs for its whole lifetime
+	if formData.RefreshToken != "" {
+		userID := h.Session.GetAuthUserID(r.Context())
+		if err := h.Services.Auth.RevokeRefreshToken(r.Context(), userID, formData.RefreshToken); err != nil {
+			h.Logger.ErrorContext(r.Context(), "WebHandler.LogoutHandler - h.Services.Auth.RevokeRefreshToken", "error", err)
+			response := responses.NewErrorJsonResponse("Could not log out, please try again")
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
+			return
+		}
+	}
+
+	// A JWT stays valid until it expires unless it is denied
+	if claims := services.UserClaimsFromContext(r.Context()); claims != nil {
+		if err := h.Services.Auth.RevokeAuthToken(r.Context(), claims); err != nil {
+			h.Logger.ErrorContext(r.Context(), "WebHandler.LogoutHandler - h.Services.Auth.RevokeAuthToken", "error", err)
+			if strings.HasPrefix(acceptType, "application/json") {
+				response := responses.NewErrorJsonResponse("Could not log out, please try again")
+				responses.WriteJsonResponse(w, http.StatusServiceUnavailable, response)
+				return
+			}
+			h.Session.SetFlashError(r.Context(), "Could not log out, please try again")
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
 	}
 
+	h.endSession(w, r)
+
 	if strings.HasPrefix(acceptType, "application/json") {
 		response := responses.NewSuccessJsonResponse("Logout successfully")
 		responses.WriteJsonResponse(w, http.StatusOK, response)
@@ -240,3 +365,50 @@ func (h *WebHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
+
+// LogoutEverywhereHandler logs the user out of every device: every JWT,
+// session and refresh token issued to them so far stops working.
+func (h *WebHandler) LogoutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+
+	userID := h.Session.GetAuthUserID(r.Context())
+	if err := h.Services.Auth.LogoutEverywhere(r.Context(), userID); err != nil {
+		h.Logger.ErrorContext(r.Context(), "WebHandler.LogoutEverywhereHandler - h.Services.Auth.LogoutEverywhere", "error", err)
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse("Could not log out everywhere, please try again")
+			responses.WriteJsonResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+		h.Session.SetFlashError(r.Context(), "Could not log out everywhere, please try again")
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	h.endSession(w, r)
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse("Logged out on every device")
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// endSession destroys the session, removes the JWT cookie and starts a new
+// CSRF token for the next login.
+func (h *WebHandler) endSession(w http.ResponseWriter, r *http.Request) {
+	h.Session.Destroy(r.Context())
+
+	cookie := h.newJwtCookie("")
+	cookie.Expires = time.Unix(0, 0)
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+
+	newCsrfToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		h.Logger.ErrorContext(r.Context(), "WebHandler.endSession - utils.GenerateRandomString", "error", err)
+	} else {
+		h.Session.SetCsrfToken(r.Context(), newCsrfToken)
+	}
+}
@@ -19,6 +19,12 @@ type AddOrder struct {
 	ProductID int `json:"productId" form:"productId" validate:"required,min=1"`
 }
 
+// SetCartQuantity is the body of a request that sets the quantity of a product
+// in the cart. Quantity is a pointer so that an explicit 0 passes "required".
+type SetCartQuantity struct {
+	Quantity *int `json:"quantity" form:"quantity" validate:"required,min=0,max=100"`
+}
+
 func (h *WebHandler) GetCartHandler(w http.ResponseWriter, r *http.Request) {
 	acceptType := r.Header.Get("Accept")
 
@@ -26,10 +32,10 @@ func (h *WebHandler) GetCartHandler(w http.ResponseWriter, r *http.Request) {
 	userID := h.Session.GetAuthUserID(r.Context())
 
 	if strings.HasPrefix(acceptType, "application/json") {
-		cart, err := h.Services.CartItem.GetCart(userID)
+		cart, err := h.getCart(r, userID)
 		if err != nil {
 			response := responses.NewErrorJsonResponse(err.Error())
-			responses.WriteJsonResponse(w, http.StatusInternalServerError, response)
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
 			return
 		}
 		response := responses.NewSuccessJsonDataResponse("Fetched Cart", cart)
@@ -69,13 +75,15 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 		err := r.ParseForm()
 		if err != nil {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: AddCartItemHandler - ParseForm - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "AddCartItemHandler - ParseForm - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, fmt.Sprintf("ERROR: Failed to parse form data: %v", err))
@@ -88,13 +96,15 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 		productId, err := strconv.Atoi(productIdStr)
 		if err != nil {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: AddCartItemHandler - Get Product - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "AddCartItemHandler - Get Product - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, fmt.Sprintf("ERROR: Invalid product ID received: %s - %v", productIdStr, err))
@@ -114,12 +124,14 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: PostRegisterHandler - GetRegisterTemplateContent on validation error: %v", templateDataErr)
-				http.Error(w, "Failed to reload index page after validation error", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "PostRegisterHandler - GetRegisterTemplateContent on validation error", "error", templateDataErr)
+				http.Error(w, "Failed to reload index page after validation error", serverErrorStatus(templateDataErr))
 				return
 			}
 
@@ -131,7 +143,7 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := h.Services.CartItem.AddCartItem(userID, addOrder.ProductID)
+	err := h.addCartItem(r, userID, addOrder.ProductID)
 	if err != nil {
 		if notFoundErr, ok := err.(*appErrors.NotFoundError); ok {
 			// Product not found when trying to add to cart
@@ -140,42 +152,71 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 				responses.WriteJsonResponse(w, http.StatusNotFound, response) // Corrected to 404 Not Found
 			} else {
 				data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+					r.Context(),
 					h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 					h.Services.HomeTemplateData.WithUserID(h.Session.GetAuthUserID(r.Context())),
+					h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 				)
 
 				if templateDataErr != nil {
-					h.Loggers.Error.Printf("ERROR: AddCartItemHandler - AddCartItem - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-					http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+					h.Logger.ErrorContext(r.Context(), "AddCartItemHandler - AddCartItem - Failed to get HTML template content", "error", templateDataErr)
+					http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 					return
 				}
 				data.Errors = append(data.Errors, notFoundErr.Error())
 				h.RenderHtmlTemplate(w, "index.html", data, http.StatusNotFound)
 			}
+		} else if errors.Is(err, models.ErrInsufficientStock) {
+			// Not enough units left to add another one to the cart
+			if strings.HasPrefix(acceptType, "application/json") {
+				response := responses.NewErrorJsonResponse(err.Error())
+				responses.WriteJsonResponse(w, http.StatusConflict, response)
+			} else {
+				data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+					r.Context(),
+					h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
+					h.Services.HomeTemplateData.WithUserID(userID),
+					h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
+				)
+
+				if templateDataErr != nil {
+					h.Logger.ErrorContext(r.Context(), "AddCartItemHandler - Insufficient Stock - Failed to get HTML template content", "error", templateDataErr)
+					http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+					return
+				}
+				data.Errors = append(data.Errors, err.Error())
+				h.RenderHtmlTemplate(w, "index.html", data, http.StatusConflict)
+			}
 		} else {
 			// Other internal errors from AddCartItem (e.g., unexpected DB error)
-			h.Loggers.Error.Printf("ERROR: Failed to add item to cart (ProductID: %d): %v", addOrder.ProductID, err)
+			h.Logger.ErrorContext(r.Context(), "Failed to add item to cart", "product_id", addOrder.ProductID, "error", err)
 			if strings.HasPrefix(acceptType, "application/json") {
 				response := responses.NewErrorJsonResponse("An internal error occurred while adding to cart.")
-				responses.WriteJsonResponse(w, http.StatusInternalServerError, response) // 500 Internal Server Error
+				responses.WriteJsonResponse(w, serverErrorStatus(err), response)
 			} else {
 				data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+					r.Context(),
 					h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 					h.Services.HomeTemplateData.WithUserID(userID),
+					h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 				)
 
 				if templateDataErr != nil {
-					h.Loggers.Error.Printf("ERROR: AddCartItemHandler - Other Internal Errors - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-					http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+					h.Logger.ErrorContext(r.Context(), "AddCartItemHandler - Other Internal Errors - Failed to get HTML template content", "error", templateDataErr)
+					http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 					return
 				}
 				data.Errors = append(data.Errors, "An internal error occurred while adding to cart.")
-				h.RenderHtmlTemplate(w, "index.html", data, http.StatusInternalServerError)
+				h.RenderHtmlTemplate(w, "index.html", data, serverErrorStatus(err))
 			}
 		}
 		return
 	}
 
+	// The counter is in units: every add puts one more unit in the cart
+	const quantity = 1
+	h.Metrics.CartItemsAdded.Add(float64(quantity))
+
 	if strings.HasPrefix(acceptType, "application/json") {
 		response := responses.NewSuccessJsonResponse("Added item from Cart")
 		responses.WriteJsonResponse(w, http.StatusCreated, response)
@@ -184,6 +225,103 @@ func (h *WebHandler) AddCartItemHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (h *WebHandler) SetCartItemQuantityHandler(w http.ResponseWriter, r *http.Request) {
+	pathId := chi.URLParam(r, "product_id")
+	productID, err := strconv.Atoi(pathId)
+	acceptType := r.Header.Get("Accept")
+	contentType := r.Header.Get("Content-Type")
+
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	// renderError answers with a JSON error or re-renders the home page with it
+	renderError := func(statusCode int, errs ...string) {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(strings.Join(errs, ", "))
+			responses.WriteJsonResponse(w, statusCode, response)
+			return
+		}
+
+		data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+			r.Context(),
+			h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
+			h.Services.HomeTemplateData.WithUserID(userID),
+			h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
+		)
+		if templateDataErr != nil {
+			h.Logger.ErrorContext(r.Context(), "SetCartItemQuantityHandler - Failed to get HTML template content", "error", templateDataErr)
+			http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+			return
+		}
+		data.Errors = append(data.Errors, errs...)
+		h.RenderHtmlTemplate(w, "index.html", data, statusCode)
+	}
+
+	if err != nil || productID < 1 {
+		renderError(http.StatusBadRequest, fmt.Sprintf("Invalid product ID: %s", pathId))
+		return
+	}
+
+	var setQuantity SetCartQuantity
+
+	if strings.HasPrefix(contentType, "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &setQuantity)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		// Html Content
+		quantityStr := r.FormValue("quantity")
+		if quantityStr != "" {
+			quantity, err := strconv.Atoi(quantityStr)
+			if err != nil {
+				renderError(http.StatusBadRequest, fmt.Sprintf("Invalid quantity received: %s", quantityStr))
+				return
+			}
+			setQuantity.Quantity = &quantity
+		}
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(setQuantity)
+	if validationErrors != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		var errs []string
+		for field, msg := range validationErrors {
+			errs = append(errs, fmt.Sprintf("%s: %s", field, msg))
+		}
+		renderError(http.StatusBadRequest, errs...)
+		return
+	}
+
+	err = h.setCartItemQuantity(r, userID, productID, *setQuantity.Quantity)
+	if err != nil {
+		if notFoundErr, ok := err.(*appErrors.NotFoundError); ok {
+			renderError(http.StatusNotFound, notFoundErr.Error())
+		} else if errors.Is(err, models.ErrInsufficientStock) {
+			renderError(http.StatusConflict, err.Error())
+		} else {
+			h.Logger.ErrorContext(r.Context(), "Failed to set cart quantity", "product_id", productID, "error", err)
+			renderError(serverErrorStatus(err), "An internal error occurred while updating the cart.")
+		}
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse("Cart Updated")
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+	} else {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
+
 func (h *WebHandler) RemoveSingleCartItemHandler(w http.ResponseWriter, r *http.Request) {
 	pathId := chi.URLParam(r, "product_id")
 	productID, err := strconv.Atoi(pathId)
@@ -199,13 +337,15 @@ func (h *WebHandler) RemoveSingleCartItemHandler(w http.ResponseWriter, r *http.
 		} else {
 
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: RemoveSingleCartItemHandler - Begin - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "RemoveSingleCartItemHandler - Begin - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
@@ -214,7 +354,7 @@ func (h *WebHandler) RemoveSingleCartItemHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err = h.Services.CartItem.RemoveSingleCartItem(userID, productID)
+	err = h.removeSingleCartItem(r, userID, productID)
 	if err != nil || err == models.ErrCartItemNotFound {
 		if notFoundErr, ok := err.(*appErrors.NotFoundError); ok {
 			if strings.HasPrefix(acceptType, "application/json") {
@@ -222,13 +362,15 @@ func (h *WebHandler) RemoveSingleCartItemHandler(w http.ResponseWriter, r *http.
 				responses.WriteJsonResponse(w, http.StatusNotFound, response)
 			} else {
 				data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+					r.Context(),
 					h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 					h.Services.HomeTemplateData.WithUserID(userID),
+					h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 				)
 
 				if templateDataErr != nil {
-					h.Loggers.Error.Printf("ERROR: RemoveSingleCartItemHandler - Failed to Remove - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-					http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+					h.Logger.ErrorContext(r.Context(), "RemoveSingleCartItemHandler - Failed to Remove - Failed to get HTML template content", "error", templateDataErr)
+					http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 					return
 				}
 				data.Errors = append(data.Errors, notFoundErr.Error())
@@ -238,20 +380,22 @@ func (h *WebHandler) RemoveSingleCartItemHandler(w http.ResponseWriter, r *http.
 		}
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
-			responses.WriteJsonResponse(w, http.StatusInternalServerError, response)
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: RemoveSingleCartItemHandler - Failed to Close - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "RemoveSingleCartItemHandler - Failed to Close - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
-			h.RenderHtmlTemplate(w, "index.html", data, http.StatusInternalServerError)
+			h.RenderHtmlTemplate(w, "index.html", data, serverErrorStatus(err))
 		}
 		return
 	}
@@ -279,13 +423,15 @@ func (h *WebHandler) RemoveCartItemHandler(w http.ResponseWriter, r *http.Reques
 			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: RemoveCartItemHandler - Begin - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "RemoveCartItemHandler - Begin - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
@@ -294,7 +440,7 @@ func (h *WebHandler) RemoveCartItemHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = h.Services.CartItem.RemoveCartItem(userID, cartItemId)
+	err = h.removeCartItem(r, userID, cartItemId)
 	if err != nil {
 		if notFoundErr, ok := err.(*appErrors.NotFoundError); ok || err == models.ErrCartItemNotFound {
 			if strings.HasPrefix(acceptType, "application/json") {
@@ -302,13 +448,15 @@ func (h *WebHandler) RemoveCartItemHandler(w http.ResponseWriter, r *http.Reques
 				responses.WriteJsonResponse(w, http.StatusNotFound, response)
 			} else {
 				data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+					r.Context(),
 					h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 					h.Services.HomeTemplateData.WithUserID(userID),
+					h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 				)
 
 				if templateDataErr != nil {
-					h.Loggers.Error.Printf("ERROR: RemoveCartItemHandler - Failed to Remove - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-					http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+					h.Logger.ErrorContext(r.Context(), "RemoveCartItemHandler - Failed to Remove - Failed to get HTML template content", "error", templateDataErr)
+					http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 					return
 				}
 				data.Errors = append(data.Errors, notFoundErr.Error())
@@ -318,20 +466,22 @@ func (h *WebHandler) RemoveCartItemHandler(w http.ResponseWriter, r *http.Reques
 		}
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
-			responses.WriteJsonResponse(w, http.StatusInternalServerError, response)
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: RemoveCartItemHandler - Failed to Close - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "RemoveCartItemHandler - Failed to Close - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
-			h.RenderHtmlTemplate(w, "index.html", data, http.StatusInternalServerError)
+			h.RenderHtmlTemplate(w, "index.html", data, serverErrorStatus(err))
 		}
 		return
 	}
@@ -351,12 +501,18 @@ func (h *WebHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
 	csrfToken := h.Session.GetCsrfToken(r.Context())
 	userID := h.Session.GetAuthUserID(r.Context())
 
-	err := h.Services.CartItem.Checkout(userID)
+	order, err := h.Services.CartItem.Checkout(r.Context(), userID)
 
 	if err != nil {
 		statusCode := http.StatusBadRequest
 		if err == models.ErrCartItemNotFound {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, models.ErrInsufficientStock) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, models.ErrEmailNotVerified) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, models.ErrQueryTimeout) {
+			statusCode = http.StatusGatewayTimeout
 		}
 
 		if strings.HasPrefix(acceptType, "application/json") {
@@ -364,13 +520,15 @@ func (h *WebHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
 			responses.WriteJsonResponse(w, statusCode, response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: CheckoutHandler - Begin - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "CheckoutHandler - Begin - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
@@ -379,9 +537,11 @@ func (h *WebHandler) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.Metrics.Checkouts.Inc()
+
 	if strings.HasPrefix(acceptType, "application/json") {
-		response := responses.NewSuccessJsonResponse("Checked Out.")
-		responses.WriteJsonResponse(w, http.StatusOK, response)
+		response := responses.NewSuccessJsonDataResponse("Checked Out.", order)
+		responses.WriteJsonResponse(w, http.StatusCreated, response)
 	} else {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
 	}
@@ -393,20 +553,22 @@ func (h *WebHandler) ConfirmOrderHandler(w http.ResponseWriter, r *http.Request)
 	csrfToken := h.Session.GetCsrfToken(r.Context())
 	userID := h.Session.GetAuthUserID(r.Context())
 
-	cart, err := h.Services.CartItem.GetCart(userID)
+	cart, err := h.Services.CartItem.GetCart(r.Context(), userID)
 	if err != nil {
 		if strings.HasPrefix(acceptType, "application/json") {
 			response := responses.NewErrorJsonResponse(err.Error())
 			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: ConfirmOrderHandler - Begin - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "ConfirmOrderHandler - Begin - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
@@ -427,13 +589,15 @@ func (h *WebHandler) ConfirmOrderHandler(w http.ResponseWriter, r *http.Request)
 			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
 		} else {
 			data, templateDataErr := h.Services.HomeTemplateData.GetHomeTemplateContent(
+				r.Context(),
 				h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 				h.Services.HomeTemplateData.WithUserID(userID),
+				h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 			)
 
 			if templateDataErr != nil {
-				h.Loggers.Error.Printf("ERROR: ConfirmOrderHandler - Failed to Checkout - Failed to get HTML template content for user %d: %v", userID, templateDataErr)
-				http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+				h.Logger.ErrorContext(r.Context(), "ConfirmOrderHandler - Failed to Checkout - Failed to get HTML template content", "error", templateDataErr)
+				http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
 				return
 			}
 			data.Errors = append(data.Errors, err.Error())
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	responses "dessert-ordering-go-system/internal/response"
+	models "dessert-ordering-go-system/models"
+	services "dessert-ordering-go-system/services"
+)
+
+// VerifyEmailHandler confirms the email address of the link's user. The link
+// is opened from an email, so the user may not be logged in.
+func (h *WebHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+
+	_, err := h.Services.EmailVerification.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		message := err.Error()
+		if !errors.Is(err, models.ErrInvalidEmailVerification) {
+			h.Logger.ErrorContext(r.Context(), "VerifyEmailHandler - h.Services.EmailVerification.VerifyEmail", "error", err)
+			statusCode = serverErrorStatus(err)
+			message = "Could not verify the email address, please try again"
+		}
+
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, statusCode, response)
+			return
+		}
+		h.Session.SetFlashError(r.Context(), message)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse("Email address verified")
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	h.Session.SetFlashMessage(r.Context(), "Your email address is verified, you can now place orders")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// ResendVerificationEmailHandler sends the logged in user a new verification
+// link, at most once per cooldown.
+func (h *WebHandler) ResendVerificationEmailHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	err := h.Services.EmailVerification.ResendVerificationEmail(r.Context(), userID)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		message := err.Error()
+
+		var cooldownErr *services.VerificationCooldownError
+		switch {
+		case errors.As(err, &cooldownErr):
+			statusCode = http.StatusTooManyRequests
+			retryAfter := int(cooldownErr.Wait.Round(time.Second).Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			statusCode = http.StatusConflict
+		default:
+			h.Logger.ErrorContext(r.Context(), "ResendVerificationEmailHandler - h.Services.EmailVerification.ResendVerificationEmail", "error", err)
+			statusCode = serverErrorStatus(err)
+			message = "Could not send the verification email, please try again"
+		}
+
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, statusCode, response)
+			return
+		}
+		h.Session.SetFlashError(r.Context(), message)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse("Verification email sent")
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	h.Session.SetFlashMessage(r.Context(), "A new verification link is on its way to your email")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	models "dessert-ordering-go-system/models"
+)
+
+// The cart routes serve both logged-in users and anonymous visitors. The helpers
+// below use the user's cart_items when someone is logged in, and the guest cart
+// kept in the session otherwise.
+
+// updateGuestCart applies fn to the session cart and saves the cart back when fn succeeds.
+func (h *WebHandler) updateGuestCart(r *http.Request, fn func(models.GuestCart) error) error {
+	cart := h.Session.GetGuestCart(r.Context())
+
+	err := fn(cart)
+	if err != nil {
+		return err
+	}
+
+	h.Session.SetGuestCart(r.Context(), cart)
+	return nil
+}
+
+func (h *WebHandler) getCart(r *http.Request, userID int) (models.Cart, error) {
+	if userID > 0 {
+		return h.Services.CartItem.GetCart(r.Context(), userID)
+	}
+	return h.Services.GuestCart.GetCart(r.Context(), h.Session.GetGuestCart(r.Context()))
+}
+
+func (h *WebHandler) addCartItem(r *http.Request, userID, productID int) error {
+	if userID > 0 {
+		return h.Services.CartItem.AddCartItem(r.Context(), userID, productID)
+	}
+	return h.updateGuestCart(r, func(cart models.GuestCart) error {
+		return h.Services.GuestCart.AddCartItem(r.Context(), cart, productID)
+	})
+}
+
+func (h *WebHandler) setCartItemQuantity(r *http.Request, userID, productID, quantity int) error {
+	if userID > 0 {
+		return h.Services.CartItem.SetCartItemQuantity(r.Context(), userID, productID, quantity)
+	}
+	return h.updateGuestCart(r, func(cart models.GuestCart) error {
+		return h.Services.GuestCart.SetCartItemQuantity(r.Context(), cart, productID, quantity)
+	})
+}
+
+func (h *WebHandler) removeSingleCartItem(r *http.Request, userID, productID int) error {
+	if userID > 0 {
+		return h.Services.CartItem.RemoveSingleCartItem(r.Context(), userID, productID)
+	}
+	return h.updateGuestCart(r, func(cart models.GuestCart) error {
+		return h.Services.GuestCart.RemoveSingleCartItem(cart, productID)
+	})
+}
+
+// removeCartItem removes a whole cart item. Guest cart items are identified by
+// their product ID.
+func (h *WebHandler) removeCartItem(r *http.Request, userID, cartItemID int) error {
+	if userID > 0 {
+		return h.Services.CartItem.RemoveCartItem(r.Context(), userID, cartItemID)
+	}
+	return h.updateGuestCart(r, func(cart models.GuestCart) error {
+		return h.Services.GuestCart.RemoveCartItem(cart, cartItemID)
+	})
+}
+
+// mergeGuestCart moves the session cart into the cart of a user who has just
+// logged in. A failed merge is logged and the guest cart is kept, but it does
+// not fail the login. Lines cut down to the stock left are flashed.
+func (h *WebHandler) mergeGuestCart(r *http.Request, userID int) {
+	cart := h.Session.GetGuestCart(r.Context())
+	if len(cart) == 0 {
+		return
+	}
+
+	reduced, err := h.Services.GuestCart.MergeIntoUserCart(r.Context(), userID, cart)
+	if err != nil {
+		h.Logger.ErrorContext(r.Context(), "mergeGuestCart - MergeIntoUserCart", "error", err)
+		return
+	}
+
+	h.Session.RemoveGuestCart(r.Context())
+
+	if len(reduced) > 0 {
+		changes := make([]string, len(reduced))
+		for i, item := range reduced {
+			changes[i] = fmt.Sprintf("%s: %d instead of %d", item.Title, item.Quantity, item.Requested)
+		}
+		h.Session.SetFlashError(r.Context(), "Your cart was reduced to what can be ordered. "+strings.Join(changes, ", "))
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	responses "dessert-ordering-go-system/internal/response"
+)
+
+// HealthzHandler reports that the process is alive. It checks no dependencies,
+// so a database outage does not get the instance restarted.
+func (h *WebHandler) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	response := responses.NewSuccessJsonResponse("OK")
+	responses.WriteJsonResponse(w, http.StatusOK, response)
+}
+
+// ReadyzHandler reports whether the instance can take traffic: the database
+// and Redis answer a ping, and no shutdown is in progress.
+func (h *WebHandler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	readiness := h.CheckReadiness(r.Context())
+
+	if !readiness.Ready {
+		message := "Not ready"
+		if readiness.ShuttingDown {
+			message = "Shutting down"
+		}
+		response := responses.NewErrorJsonDataResponse(message, readiness)
+		responses.WriteJsonResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	response := responses.NewSuccessJsonDataResponse("Ready", readiness)
+	responses.WriteJsonResponse(w, http.StatusOK, response)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"log"
 	"net/http"
 	"strings"
 
@@ -33,8 +32,10 @@ func (h *WebHandler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	htmlContent, err := h.Services.HomeTemplateData.GetHomeTemplateContent(
+		r.Context(),
 		h.Services.HomeTemplateData.WithCsrfToken(csrfToken),
 		h.Services.HomeTemplateData.WithUserID(h.Session.GetAuthUserID(r.Context())),
+		h.Services.HomeTemplateData.WithGuestCart(h.Session.GetGuestCart(r.Context())),
 	)
 	sessionFlashError := h.Session.PopString(r.Context(), appConstants.Flash_Error)
 	if sessionFlashError != "" {
@@ -42,10 +43,23 @@ func (h *WebHandler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		log.Printf("ERROR: HomeHandler - Failed to get HTML template content for user %d: %v", 1, err)
-		http.Error(w, "Failed to load page content", http.StatusInternalServerError)
+		h.Logger.ErrorContext(r.Context(), "HomeHandler - Failed to get HTML template content", "error", err)
+		http.Error(w, "Failed to load page content", serverErrorStatus(err))
 		return
 	}
+	sessionFlashMessage := h.Session.PopFlashMessage(r.Context())
+	if sessionFlashMessage != "" {
+		htmlContent.Messages = append(htmlContent.Messages, sessionFlashMessage)
+	}
+
+	// Unverified users cannot check out, so tell them before they try
+	if htmlContent.UserID > 0 {
+		verified, err := h.Services.EmailVerification.IsEmailVerified(r.Context(), htmlContent.UserID)
+		if err != nil {
+			h.Logger.ErrorContext(r.Context(), "HomeHandler - h.Services.EmailVerification.IsEmailVerified", "error", err)
+		}
+		htmlContent.IsEmailUnverified = err == nil && !verified
+	}
 
 	h.RenderHtmlTemplate(w, "index.html", htmlContent, http.StatusOK)
 }
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	jwtkeys "dessert-ordering-go-system/internal/jwtkeys"
+	responses "dessert-ordering-go-system/internal/response"
+)
+
+// JWKSHandler serves the public keys that verify the JWTs, in the plain JWKS
+// format other services expect rather than the usual response envelope. With
+// HS256 there is no public key, and the set is empty.
+func (h *WebHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	set := jwtkeys.JWKSet{Keys: []jwtkeys.JWK{}}
+	if h.JWT.Keys != nil {
+		set = h.JWT.Keys.JWKS()
+	}
+
+	// Verifiers may cache the keys for a while: a new key is published for
+	// longer than that before it signs
+	responses.WriteJsonHeadersResponse(w, http.StatusOK, set, map[string]string{
+		"Cache-Control": fmt.Sprintf("public, max-age=%d", int(jwtkeys.JWKSMaxAge.Seconds())),
+	})
+}
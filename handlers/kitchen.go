@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	appConstants "dessert-ordering-go-system/internal/app_constants"
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	responses "dessert-ordering-go-system/internal/response"
+	models "dessert-ordering-go-system/models"
+	services "dessert-ordering-go-system/services"
+)
+
+func (h *WebHandler) GetKitchenOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		orders, err := h.Services.Kitchen.GetQueue(r.Context())
+		if err != nil {
+			h.Logger.ErrorContext(r.Context(), "GetKitchenOrdersHandler - GetQueue", "error", err)
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
+			return
+		}
+		response := responses.NewSuccessJsonDataResponse("Fetched Orders", orders)
+		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
+		return
+	}
+
+	data, templateDataErr := h.Services.KitchenTemplateData.GetKitchenTemplateContent(
+		r.Context(),
+		h.Services.KitchenTemplateData.WithCsrfToken(csrfToken),
+	)
+	if templateDataErr != nil {
+		h.Logger.ErrorContext(r.Context(), "GetKitchenOrdersHandler - GetKitchenTemplateContent", "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+		return
+	}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	h.RenderHtmlTemplate(w, "kitchen.html", data, http.StatusOK)
+}
+
+func (h *WebHandler) UpdateKitchenOrderStatusHandler(w http.ResponseWriter, r *http.Request) {
+	pathId := chi.URLParam(r, "id")
+	orderID, err := strconv.Atoi(pathId)
+	acceptType := r.Header.Get("Accept")
+
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	if err != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse("Invalid Order ID")
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), "Invalid Order ID")
+			http.Redirect(w, r, "/kitchen/orders", http.StatusSeeOther)
+		}
+		return
+	}
+
+	var form services.OrderStatusForm
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &form)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		form.Status = r.FormValue("status")
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(form)
+	if validationErrors != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), "Invalid order status")
+			http.Redirect(w, r, "/kitchen/orders", http.StatusSeeOther)
+		}
+		return
+	}
+
+	order, err := h.Services.OrderStatus.UpdateStatus(r.Context(), orderID, models.OrderStatus(form.Status), userID)
+	if err != nil {
+		statusCode := serverErrorStatus(err)
+		message := err.Error()
+
+		if _, ok := err.(*appErrors.NotFoundError); ok {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, models.ErrInvalidStatusTransition) {
+			statusCode = http.StatusConflict
+		} else {
+			h.Logger.ErrorContext(r.Context(), "UpdateKitchenOrderStatusHandler - UpdateStatus", "order_id", orderID, "error", err)
+			message = "An internal error occurred while updating the order."
+		}
+
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, statusCode, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), message)
+			http.Redirect(w, r, "/kitchen/orders", http.StatusSeeOther)
+		}
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonDataResponse("Order Updated", order)
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+	} else {
+		http.Redirect(w, r, "/kitchen/orders", http.StatusSeeOther)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	appConstants "dessert-ordering-go-system/internal/app_constants"
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	responses "dessert-ordering-go-system/internal/response"
+	models "dessert-ordering-go-system/models"
+	services "dessert-ordering-go-system/services"
+)
+
+// queryInt reads an optional positive integer query parameter.
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, fmt.Errorf("invalid %s: %q must be a positive number", name, value)
+	}
+	return parsed, nil
+}
+
+func (h *WebHandler) GetOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	page, pageErr := queryInt(r, "page", 1)
+	perPage, perPageErr := queryInt(r, "perPage", services.DefaultOrdersPerPage)
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		if pageErr != nil || perPageErr != nil {
+			validationErrors := map[string]string{}
+			if pageErr != nil {
+				validationErrors["page"] = pageErr.Error()
+			}
+			if perPageErr != nil {
+				validationErrors["perPage"] = perPageErr.Error()
+			}
+			response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+			return
+		}
+
+		orderPage, err := h.Services.Order.GetOrders(r.Context(), userID, page, perPage)
+		if err != nil {
+			h.Logger.ErrorContext(r.Context(), "GetOrdersHandler - GetOrders", "error", err)
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, serverErrorStatus(err), response)
+			return
+		}
+		response := responses.NewSuccessJsonDataResponse("Fetched Orders", orderPage)
+		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
+		return
+	}
+
+	statusCode := http.StatusOK
+	var errs []string
+	if pageErr != nil {
+		statusCode = http.StatusBadRequest
+		errs = append(errs, pageErr.Error())
+		page = 1
+	}
+	if perPageErr != nil {
+		statusCode = http.StatusBadRequest
+		errs = append(errs, perPageErr.Error())
+		perPage = services.DefaultOrdersPerPage
+	}
+
+	data, templateDataErr := h.Services.OrdersTemplateData.GetOrdersTemplateContent(
+		r.Context(),
+		h.Services.OrdersTemplateData.WithCsrfToken(csrfToken),
+		h.Services.OrdersTemplateData.WithUserID(userID),
+		h.Services.OrdersTemplateData.WithPage(page, perPage),
+		h.Services.OrdersTemplateData.WithErrors(errs),
+	)
+	if templateDataErr != nil {
+		h.Logger.ErrorContext(r.Context(), "GetOrdersHandler - GetOrdersTemplateContent", "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+		return
+	}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	h.RenderHtmlTemplate(w, "orders.html", data, statusCode)
+}
+
+func (h *WebHandler) GetOrderDetailHandler(w http.ResponseWriter, r *http.Request) {
+	pathId := chi.URLParam(r, "id")
+	orderID, err := strconv.Atoi(pathId)
+	acceptType := r.Header.Get("Accept")
+
+	csrfToken := h.Session.GetCsrfToken(r.Context())
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	if err != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse("Invalid Order ID")
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), "Invalid Order ID")
+			http.Redirect(w, r, "/orders", http.StatusSeeOther)
+		}
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		order, err := h.Services.Order.GetOrderDetail(r.Context(), userID, orderID)
+		if err != nil {
+			statusCode := serverErrorStatus(err)
+			if _, ok := err.(*appErrors.NotFoundError); ok {
+				statusCode = http.StatusNotFound
+			} else {
+				h.Logger.ErrorContext(r.Context(), "GetOrderDetailHandler - GetOrderDetail", "order_id", orderID, "error", err)
+			}
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, statusCode, response)
+			return
+		}
+		response := responses.NewSuccessJsonDataResponse("Fetched Order", order)
+		responses.WriteJsonHeadersResponse(w, http.StatusOK, response, map[string]string{appConstants.X_CSRF_Token: csrfToken})
+		return
+	}
+
+	data, templateDataErr := h.Services.OrdersTemplateData.GetOrderTemplateContent(
+		r.Context(),
+		orderID,
+		h.Services.OrdersTemplateData.WithCsrfToken(csrfToken),
+		h.Services.OrdersTemplateData.WithUserID(userID),
+	)
+	if templateDataErr != nil {
+		if notFoundErr, ok := templateDataErr.(*appErrors.NotFoundError); ok {
+			h.Session.SetFlashError(r.Context(), notFoundErr.Error())
+			http.Redirect(w, r, "/orders", http.StatusSeeOther)
+			return
+		}
+		h.Logger.ErrorContext(r.Context(), "GetOrderDetailHandler - GetOrderTemplateContent", "order_id", orderID, "error", templateDataErr)
+		http.Error(w, "Failed to load page content", serverErrorStatus(templateDataErr))
+		return
+	}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	h.RenderHtmlTemplate(w, "order.html", data, http.StatusOK)
+}
+
+func (h *WebHandler) CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	pathId := chi.URLParam(r, "id")
+	orderID, err := strconv.Atoi(pathId)
+	acceptType := r.Header.Get("Accept")
+
+	userID := h.Session.GetAuthUserID(r.Context())
+
+	if err != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse("Invalid Order ID")
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), "Invalid Order ID")
+			http.Redirect(w, r, "/orders", http.StatusSeeOther)
+		}
+		return
+	}
+
+	order, err := h.Services.OrderStatus.CancelOrder(r.Context(), userID, orderID)
+	if err != nil {
+		statusCode := serverErrorStatus(err)
+		message := err.Error()
+		redirectTo := fmt.Sprintf("/orders/%d", orderID)
+
+		if _, ok := err.(*appErrors.NotFoundError); ok {
+			statusCode = http.StatusNotFound
+			redirectTo = "/orders"
+		} else if errors.Is(err, models.ErrInvalidStatusTransition) {
+			statusCode = http.StatusConflict
+		} else {
+			h.Logger.ErrorContext(r.Context(), "CancelOrderHandler - CancelOrder", "order_id", orderID, "error", err)
+			message = "An internal error occurred while cancelling the order."
+		}
+
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, statusCode, response)
+		} else {
+			h.Session.SetFlashError(r.Context(), message)
+			http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+		}
+		return
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonDataResponse("Order Cancelled", order)
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+	} else {
+		http.Redirect(w, r, fmt.Sprintf("/orders/%d", orderID), http.StatusSeeOther)
+	}
+}
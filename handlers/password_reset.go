@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	responses "dessert-ordering-go-system/internal/response"
+	models "dessert-ordering-go-system/models"
+	services "dessert-ordering-go-system/services"
+)
+
+// ****** Forgot Password Handlers *******
+
+func (h *WebHandler) GetForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	data := &services.PasswordResetTemplateData{CsrfToken: h.Session.GetCsrfToken(r.Context())}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	h.RenderHtmlTemplate(w, "forgot_password.html", data, http.StatusOK)
+}
+
+// PostForgotPasswordHandler emails a password reset link. It answers the same
+// whether or not an account uses the email.
+func (h *WebHandler) PostForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+	data := &services.PasswordResetTemplateData{CsrfToken: h.Session.GetCsrfToken(r.Context())}
+
+	var formData services.ForgotPasswordForm
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &formData)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		formData.Email = r.FormValue("email")
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(formData)
+	if validationErrors != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			for field, msg := range validationErrors {
+				data.Errors = append(data.Errors, fmt.Sprintf("%s: %s", field, msg))
+			}
+			h.RenderHtmlTemplate(w, "forgot_password.html", data, http.StatusBadRequest)
+		}
+		return
+	}
+
+	// A failure is only logged: answering differently would tell that the
+	// email belongs to an account, as unknown emails never fail
+	err := h.Services.PasswordReset.RequestPasswordReset(r.Context(), formData.Email)
+	if err != nil {
+		h.Logger.ErrorContext(r.Context(), "PostForgotPasswordHandler - h.Services.PasswordReset.RequestPasswordReset", "error", err)
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse(services.PasswordResetRequestedMessage)
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	data.Messages = append(data.Messages, services.PasswordResetRequestedMessage)
+	h.RenderHtmlTemplate(w, "forgot_password.html", data, http.StatusOK)
+}
+
+// ****** Reset Password Handlers *******
+
+// GetResetPasswordHandler shows the form that sets a new password. The token
+// comes from the link in the email and is only checked once the form is sent.
+func (h *WebHandler) GetResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	data := &services.PasswordResetTemplateData{
+		CsrfToken: h.Session.GetCsrfToken(r.Context()),
+		Token:     r.URL.Query().Get("token"),
+	}
+
+	flashError := h.Session.PopFlashError(r.Context())
+	if flashError != "" {
+		data.Errors = append(data.Errors, flashError)
+	}
+	if data.Token == "" {
+		data.Errors = append(data.Errors, models.ErrInvalidPasswordReset.Error())
+	}
+
+	// Keep the token in the URL out of the Referer of anything the page loads
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	h.RenderHtmlTemplate(w, "reset_password.html", data, http.StatusOK)
+}
+
+// PostResetPasswordHandler sets the new password and logs the user out of
+// every device, in case someone else was using the forgotten password.
+func (h *WebHandler) PostResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	acceptType := r.Header.Get("Accept")
+
+	var formData services.ResetPasswordForm
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		errStatusCode, err := JsonBodyDecoder(w, r, &formData)
+		if err != nil {
+			response := responses.NewErrorJsonResponse(err.Error())
+			responses.WriteJsonResponse(w, errStatusCode, response)
+			return
+		}
+	} else {
+		formData.Token = r.FormValue("token")
+		formData.Password = r.FormValue("password")
+	}
+	data := &services.PasswordResetTemplateData{
+		CsrfToken: h.Session.GetCsrfToken(r.Context()),
+		Token:     formData.Token,
+	}
+
+	// -- Perform Validation --
+	validationErrors := h.Validator.ValidateStruct(formData)
+	if validationErrors != nil {
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonDataResponse("Validation failed", validationErrors)
+			responses.WriteJsonResponse(w, http.StatusBadRequest, response)
+		} else {
+			for field, msg := range validationErrors {
+				data.Errors = append(data.Errors, fmt.Sprintf("%s: %s", field, msg))
+			}
+			h.RenderHtmlTemplate(w, "reset_password.html", data, http.StatusBadRequest)
+		}
+		return
+	}
+
+	userID, err := h.Services.PasswordReset.ResetPassword(r.Context(), formData.Token, formData.Password)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		message := err.Error()
+		if !errors.Is(err, models.ErrInvalidPasswordReset) {
+			h.Logger.ErrorContext(r.Context(), "PostResetPasswordHandler - h.Services.PasswordReset.ResetPassword", "error", err)
+			statusCode = serverErrorStatus(err)
+			message = "Could not reset the password, please try again"
+		}
+
+		if strings.HasPrefix(acceptType, "application/json") {
+			response := responses.NewErrorJsonResponse(message)
+			responses.WriteJsonResponse(w, statusCode, response)
+		} else {
+			data.Errors = append(data.Errors, message)
+			h.RenderHtmlTemplate(w, "reset_password.html", data, statusCode)
+		}
+		return
+	}
+
+	// The password has changed either way, so a failure here is only logged
+	if err := h.Services.Auth.LogoutEverywhere(r.Context(), userID); err != nil {
+		h.Logger.ErrorContext(r.Context(), "PostResetPasswordHandler - h.Services.Auth.LogoutEverywhere", "error", err)
+	}
+
+	if strings.HasPrefix(acceptType, "application/json") {
+		response := responses.NewSuccessJsonResponse("Password reset, you can log in with the new password")
+		responses.WriteJsonResponse(w, http.StatusOK, response)
+		return
+	}
+
+	h.Session.SetFlashMessage(r.Context(), "Your password has been reset, log in with the new one")
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
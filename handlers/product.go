@@ -11,10 +11,10 @@ import (
 )
 
 func (h *WebHandler) GetProductsHandler(w http.ResponseWriter, r *http.Request) {
-	products, err := h.Services.Product.GetAllProducts()
+	products, err := h.Services.Product.GetAllProducts(r.Context())
 	if err != nil {
 		response := responses.NewErrorJsonResponse("Failed to fetch products: " + err.Error())
-		responses.WriteJsonResponse(w, http.StatusInternalServerError, response)
+		responses.WriteJsonResponse(w, serverErrorStatus(err), response)
 		return
 	}
 	response := responses.NewSuccessJsonDataResponse("Fetched Products", products)
@@ -31,10 +31,10 @@ func (h *WebHandler) GetProductDetailHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	product, err := h.Services.Product.GetProductDetail(productID)
+	product, err := h.Services.Product.GetProductDetail(r.Context(), productID)
 	if err != nil {
 		response := responses.NewErrorJsonResponse(err.Error())
-		statusCode := http.StatusInternalServerError
+		statusCode := serverErrorStatus(err)
 		// Check if it's a not found error
 		if _, ok := err.(*appErrors.NotFoundError); ok {
 			statusCode = http.StatusNotFound
@@ -5,10 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+
+	models "dessert-ordering-go-system/models"
 )
 
+// serverErrorStatus returns the status code for an unexpected service error:
+// 504 Gateway Timeout when the database did not answer in time, so clients
+// know the request may be retried, and 500 Internal Server Error otherwise.
+func serverErrorStatus(err error) int {
+	if errors.Is(err, models.ErrQueryTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
 // DecodeJSONBody decodes the JSON request body into the provided 'v' struct.
 // It returns an error representing the decoding issue and the appropriate
 // HTTP status code to send to the client. If decoding is successful,
@@ -55,7 +67,7 @@ func JsonBodyDecoder(w http.ResponseWriter, r *http.Request, v any) (int, error)
 		default:
 			// Catch-all for other unexpected decoding errors
 			// Log this full error on the server side for debugging
-			log.Printf("ERROR: Unhandled JSON decoding error: %v", err)
+			slog.ErrorContext(r.Context(), "Unhandled JSON decoding error", "error", err)
 			return http.StatusInternalServerError, errors.New("failed to parse request body")
 		}
 	}
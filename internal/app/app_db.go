@@ -5,17 +5,42 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	_ "modernc.org/sqlite"
+
+	models "dessert-ordering-go-system/models"
 )
 
-func openDB(dsn string) (*sql.DB, error) {
+func OpenDB(driver, dsn string) (*sql.DB, error) {
+	if !models.Dialect(driver).IsValid() {
+		return nil, fmt.Errorf("unsupported database driver %q: use mysql, sqlite or memory", driver)
+	}
 	if dsn == "" {
 		return nil, fmt.Errorf("dsn environment variable not set or loaded")
 	}
 	// sql.Open doesn't actually connect to the database yet; it just validates the DSN format.
-	db, err := sql.Open("mysql", dsn)
+	// Every statement is recorded as a span of the request's trace.
+	db, err := otelsql.Open(driver, dsn,
+		otelsql.WithAttributes(dbSystem(driver)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		return nil, err
 	}
+
+	// SQLite has no row locks, so transactions are serialized on a single
+	// connection instead. This keeps two checkouts from selling the same unit.
+	if models.Dialect(driver) == models.DialectSQLite {
+		db.SetMaxOpenConns(1)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -27,3 +52,11 @@ func openDB(dsn string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// dbSystem returns the OpenTelemetry name of the database behind driver.
+func dbSystem(driver string) attribute.KeyValue {
+	if models.Dialect(driver) == models.DialectSQLite {
+		return semconv.DBSystemNameSQLite
+	}
+	return semconv.DBSystemNameMySQL
+}
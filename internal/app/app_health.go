@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ReadinessTimeout bounds each dependency check of the readiness probe.
+const ReadinessTimeout = 2 * time.Second
+
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// DependencyStatus is the outcome of pinging a single dependency.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Readiness reports whether the instance can serve traffic.
+type Readiness struct {
+	Ready        bool                         `json:"ready"`
+	ShuttingDown bool                         `json:"shutting_down"`
+	Dependencies map[string]*DependencyStatus `json:"dependencies"`
+}
+
+// BeginShutdown marks the application as shutting down, which makes the
+// readiness probe fail so that load balancers stop sending new requests.
+func (a *Application) BeginShutdown() {
+	a.shuttingDown.Store(true)
+}
+
+func (a *Application) IsShuttingDown() bool {
+	return a.shuttingDown.Load()
+}
+
+// CheckReadiness pings the database and Redis at the same time, each with its
+// own ReadinessTimeout. The in-memory store has no connection to check.
+func (a *Application) CheckReadiness(ctx context.Context) *Readiness {
+	checks := map[string]func(ctx context.Context) error{
+		"redis": a.pingRedis,
+	}
+	if a.DB != nil {
+		checks["database"] = a.DB.PingContext
+	}
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		dependencies = make(map[string]*DependencyStatus, len(checks))
+	)
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := checkDependency(ctx, check)
+
+			mu.Lock()
+			dependencies[name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	readiness := &Readiness{
+		Ready:        !a.IsShuttingDown(),
+		ShuttingDown: a.IsShuttingDown(),
+		Dependencies: dependencies,
+	}
+	for _, status := range dependencies {
+		if status.Status != DependencyUp {
+			readiness.Ready = false
+		}
+	}
+	return readiness
+}
+
+func checkDependency(ctx context.Context, check func(ctx context.Context) error) *DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	latency := time.Since(start)
+
+	status := &DependencyStatus{
+		Status:    DependencyUp,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = DependencyDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (a *Application) pingRedis(ctx context.Context) error {
+	conn, err := a.RedisPool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "PING")
+	return err
+}
@@ -1,19 +1,83 @@
 package app
 
-import "os"
+import (
+	"context"
+	"time"
+
+	config "dessert-ordering-go-system/internal/config"
+	jwtkeys "dessert-ordering-go-system/internal/jwtkeys"
+)
+
+// keyRotationCheckInterval is how often the rotation worker looks at the age
+// of the signing key.
+const keyRotationCheckInterval = time.Minute
 
 type ApplicationJwt struct {
-	SECRET string
+	SECRET            string
+	Expiration        time.Duration
+	RefreshExpiration time.Duration
+	// Keys holds the RS256 or EdDSA keys; it is nil with HS256
+	Keys        *jwtkeys.Keyring
+	KeyRotation time.Duration
 }
 
-func NewApplicationJwt(loggers *ApplicationLoggers) *ApplicationJwt {
-	secret := os.Getenv("JWT_SECRET_KEY")
+func NewApplicationJwt(cfg config.JWTConfig) (*ApplicationJwt, error) {
+	appJwt := &ApplicationJwt{
+		SECRET:            cfg.SecretKey,
+		Expiration:        cfg.Expiration,
+		RefreshExpiration: cfg.RefreshExpiration,
+		KeyRotation:       cfg.KeyRotation,
+	}
 
-	if secret == "" {
-		loggers.Error.Fatalf("error: JWT_SECRET_KEY is not valid.")
+	if cfg.IsAsymmetric() {
+		keys, err := jwtkeys.Open(cfg.KeysDir, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		appJwt.Keys = keys
 	}
 
-	return &ApplicationJwt{
-		SECRET: secret,
+	return appJwt, nil
+}
+
+// rotateJwtKeys makes a new signing key once the current one is older than
+// JWT_KEY_ROTATION, and deletes the keys no live token can have been signed
+// with. Instances sharing JWT_KEYS_DIR pick up each other's keys on reload.
+func (a *Application) rotateJwtKeys(ctx context.Context) {
+	keys := a.JWT.Keys
+
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := keys.Reload(); err != nil {
+			a.Logger.Error("Failed to reload JWT keys", "error", err)
+			continue
+		}
+
+		// The latest key counts even before it signs, or every check during
+		// its activation delay would create another one
+		if time.Since(keys.LatestKey().CreatedAt) >= a.JWT.KeyRotation {
+			key, err := keys.Rotate()
+			if err != nil {
+				a.Logger.Error("Failed to rotate the JWT signing key", "error", err)
+				continue
+			}
+			a.Logger.Info("Rotated the JWT signing key", "kid", key.ID)
+		}
+
+		pruned, err := keys.Prune(a.JWT.Expiration)
+		if err != nil {
+			a.Logger.Error("Failed to delete expired JWT keys", "error", err)
+		}
+		if len(pruned) > 0 {
+			a.Logger.Info("Deleted expired JWT keys", "kids", pruned)
+		}
 	}
 }
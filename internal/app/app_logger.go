@@ -1,18 +1,17 @@
 package app
 
 import (
-	"log"
+	"log/slog"
 	"os"
+
+	config "dessert-ordering-go-system/internal/config"
+	logging "dessert-ordering-go-system/internal/logging"
 )
 
-type ApplicationLoggers struct {
-	Error *log.Logger
-	Info  *log.Logger
+// NewApplicationLogger returns the logger of the application and makes it the
+// slog default, which models and services log through.
+func NewApplicationLogger(cfg config.LogConfig) *slog.Logger {
+	logger := logging.New(os.Stdout, cfg.Format, cfg.SlogLevel())
+	slog.SetDefault(logger)
+	return logger
 }
-
-func NewApplicationLoggers() *ApplicationLoggers {
-	return &ApplicationLoggers{
-		Error: log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime),
-		Info:  log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime),
-	}
-}
\ No newline at end of file
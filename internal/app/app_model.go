@@ -2,22 +2,69 @@ package app
 
 import (
 	"database/sql"
+	"time"
+
 	models "dessert-ordering-go-system/models"
+	memory "dessert-ordering-go-system/models/memory"
+	traced "dessert-ordering-go-system/models/traced"
 )
 
 type ApplicationModels struct {
-	// Reference the types from the 'models' package
-	CartItem     *models.CartItemModel
-	Product      *models.ProductModel
-	ProductImage *models.ProductImageModel
-	User         *models.UserModel
+	// Reference the repository interfaces from the 'models' package
+	CartItem          models.CartRepository
+	EmailVerification models.EmailVerificationRepository
+	Order             models.OrderRepository
+	PasswordReset     models.PasswordResetRepository
+	Product           models.ProductRepository
+	ProductImage      models.ProductImageRepository
+	RefreshToken      models.RefreshTokenRepository
+	User              models.UserRepository
+}
+
+// NewApplicationModels returns the SQL models for a MySQL or SQLite database.
+// Every model call is abandoned once queryTimeout has passed.
+func NewApplicationModels(db *sql.DB, driver string, queryTimeout time.Duration) *ApplicationModels {
+	dialect := models.Dialect(driver)
+
+	return &ApplicationModels{
+		CartItem:          &models.CartItemModel{DB: db, QueryTimeout: queryTimeout},
+		EmailVerification: &models.EmailVerificationModel{DB: db, QueryTimeout: queryTimeout},
+		Order:             &models.OrderModel{DB: db, Dialect: dialect, QueryTimeout: queryTimeout},
+		PasswordReset:     &models.PasswordResetModel{DB: db, QueryTimeout: queryTimeout},
+		Product:           &models.ProductModel{DB: db, Dialect: dialect, QueryTimeout: queryTimeout},
+		ProductImage:      &models.ProductImageModel{DB: db, Dialect: dialect, QueryTimeout: queryTimeout},
+		RefreshToken:      &models.RefreshTokenModel{DB: db, QueryTimeout: queryTimeout},
+		User:              &models.UserModel{DB: db, QueryTimeout: queryTimeout},
+	}
+}
+
+// NewMemoryApplicationModels returns repositories that share one in-memory store.
+func NewMemoryApplicationModels() *ApplicationModels {
+	store := memory.NewStore()
+
+	return &ApplicationModels{
+		CartItem:          store,
+		EmailVerification: store,
+		Order:             store,
+		PasswordReset:     store,
+		Product:           store,
+		ProductImage:      store,
+		RefreshToken:      store,
+		User:              store,
+	}
 }
 
-func NewApplicationModels(db *sql.DB) *ApplicationModels {
+// WithTracing returns models whose every call is recorded as a span of the
+// request's trace.
+func (m *ApplicationModels) WithTracing() *ApplicationModels {
 	return &ApplicationModels{
-		CartItem:     &models.CartItemModel{DB: db},
-		Product:      &models.ProductModel{DB: db},
-		ProductImage: &models.ProductImageModel{DB: db},
-		User:         &models.UserModel{DB: db},
+		CartItem:          traced.NewCartRepository(m.CartItem),
+		EmailVerification: traced.NewEmailVerificationRepository(m.EmailVerification),
+		Order:             traced.NewOrderRepository(m.Order),
+		PasswordReset:     traced.NewPasswordResetRepository(m.PasswordReset),
+		Product:           traced.NewProductRepository(m.Product),
+		ProductImage:      traced.NewProductImageRepository(m.ProductImage),
+		RefreshToken:      traced.NewRefreshTokenRepository(m.RefreshToken),
+		User:              traced.NewUserRepository(m.User),
 	}
 }
@@ -1,20 +1,21 @@
 package app
 
 import (
-	"os"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+
+	config "dessert-ordering-go-system/internal/config"
 )
 
-func openRedisPool() (*redis.Pool, error) {
+func openRedisPool(cfg config.RedisConfig) (*redis.Pool, error) {
 	redisPool := &redis.Pool{
-		MaxIdle: 10,
+		MaxIdle: cfg.MaxIdle,
 		Dial: func() (redis.Conn, error) {
 			return redis.Dial(
 				"tcp",
-				os.Getenv("REDIS_ADDR"),
-				redis.DialPassword(os.Getenv("REDIS_PASSWORD")),
+				cfg.Addr,
+				redis.DialPassword(cfg.Password),
 			)
 		},
 		TestOnBorrow: func(c redis.Conn, t time.Time) error {
@@ -22,7 +23,7 @@ func openRedisPool() (*redis.Pool, error) {
 			_, err := c.Do("PING")
 			return err
 		},
-		IdleTimeout: 240 * time.Second,
+		IdleTimeout: cfg.IdleTimeout,
 	}
 
 	// Test Redis connection by getting and immediately releasing a connection
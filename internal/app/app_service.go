@@ -1,26 +1,50 @@
 package app
 
 import (
+	config "dessert-ordering-go-system/internal/config"
+	mailer "dessert-ordering-go-system/internal/mailer"
 	services "dessert-ordering-go-system/services"
 )
 
 type ApplicationServices struct {
 	// Reference the types from the 'services' package
-	Auth                 *services.AuthService
-	CartItem             *services.CartItemService
-	Product              *services.ProductService
-	HomeTemplateData     *services.HomeTemplateDataService
-	LoginTemplateData    *services.LoginTemplateDataService
-	RegisterTemplateData *services.RegisterTemplateDataService
+	AdminProductsTemplateData *services.AdminProductsTemplateDataService
+	Auth                      *services.AuthService
+	CartItem                  *services.CartItemService
+	EmailVerification         *services.EmailVerificationService
+	GuestCart                 *services.GuestCartService
+	Product                   *services.ProductService
+	Order                     *services.OrderService
+	OrderStatus               *services.OrderStatusService
+	HomeTemplateData          *services.HomeTemplateDataService
+	Kitchen                   *services.KitchenService
+	KitchenTemplateData       *services.KitchenTemplateDataService
+	LoginTemplateData         *services.LoginTemplateDataService
+	OrdersTemplateData        *services.OrdersTemplateDataService
+	PasswordReset             *services.PasswordResetService
+	RegisterTemplateData      *services.RegisterTemplateDataService
 }
 
-func NewApplicationServices(models *ApplicationModels, JWT *ApplicationJwt) *ApplicationServices {
+func NewApplicationServices(models *ApplicationModels, JWT *ApplicationJwt, denylist services.TokenDenylist, m mailer.Mailer, cfg *config.Config) *ApplicationServices {
+	orderService := services.NewOrderService(models.Order)
+	productService := services.NewProductService(models.Product, models.ProductImage)
+	kitchenService := services.NewKitchenService(models.Order)
+
 	return &ApplicationServices{
-		Auth:                 services.NewAuthService(models.User, JWT.SECRET),
-		CartItem:             services.NewCartItemService(models.CartItem),
-		Product:              services.NewProductService(models.Product),
-		HomeTemplateData:     services.NewHomeTemplateDataService(models.CartItem, models.Product),
-		LoginTemplateData:    services.NewLoginTemplateDataService(),
-		RegisterTemplateData: services.NewRegisterTemplateDataService(),
+		AdminProductsTemplateData: services.NewAdminProductsTemplateDataService(productService),
+		Auth:                      services.NewAuthService(models.User, models.RefreshToken, denylist, JWT.SECRET, JWT.Keys, JWT.Expiration, JWT.RefreshExpiration),
+		CartItem:                  services.NewCartItemService(models.CartItem, models.Order, models.User),
+		EmailVerification:         services.NewEmailVerificationService(models.User, models.EmailVerification, m, cfg.Mail.BaseURL, cfg.Account.EmailVerificationExpiration, cfg.Account.VerificationResendCooldown),
+		GuestCart:                 services.NewGuestCartService(models.CartItem, models.Product),
+		Product:                   productService,
+		Order:                     orderService,
+		OrderStatus:               services.NewOrderStatusService(models.Order),
+		HomeTemplateData:          services.NewHomeTemplateDataService(models.CartItem, models.Product),
+		Kitchen:                   kitchenService,
+		KitchenTemplateData:       services.NewKitchenTemplateDataService(kitchenService),
+		LoginTemplateData:         services.NewLoginTemplateDataService(),
+		OrdersTemplateData:        services.NewOrdersTemplateDataService(orderService),
+		PasswordReset:             services.NewPasswordResetService(models.User, models.PasswordReset, m, cfg.Mail.BaseURL, cfg.Account.PasswordResetExpiration, cfg.Account.PasswordResetCooldown),
+		RegisterTemplateData:      services.NewRegisterTemplateDataService(),
 	}
 }
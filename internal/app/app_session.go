@@ -2,15 +2,17 @@ package app
 
 import (
 	"context"
-	"log"
+	"encoding/gob"
+	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/alexedwards/scs/redisstore"
 	"github.com/alexedwards/scs/v2"
 
 	appConstants "dessert-ordering-go-system/internal/app_constants"
+	config "dessert-ordering-go-system/internal/config"
 	utils "dessert-ordering-go-system/internal/utils"
+	models "dessert-ordering-go-system/models"
 )
 
 type ApplicationSession struct {
@@ -21,18 +23,17 @@ func NewApplicationSession(s *scs.SessionManager) *ApplicationSession {
 	return &ApplicationSession{s}
 }
 
-func openSession(loggers *ApplicationLoggers, redisStore *redisstore.RedisStore) *scs.SessionManager {
+func openSession(cfg config.SessionConfig, store scs.Store) *scs.SessionManager {
+	// Session values are gob encoded, so custom types stored in them must be registered
+	gob.Register(models.GuestCart{})
+
 	sessionManager := scs.New()
-	sessionManager.Lifetime = 12 * time.Hour
+	sessionManager.Lifetime = cfg.Lifetime
 	sessionManager.Cookie.Persist = true
 	sessionManager.Cookie.SameSite = http.SameSiteLaxMode
 	sessionManager.Cookie.HttpOnly = true
-	secureCookies, err := appConstants.GetSecureCookies()
-	if err != nil {
-		loggers.Info.Println(err.Error())
-	}
-	sessionManager.Cookie.Secure = secureCookies
-	sessionManager.Store = redisStore
+	sessionManager.Cookie.Secure = cfg.SecureCookies
+	sessionManager.Store = store
 	return sessionManager
 }
 
@@ -46,12 +47,51 @@ func (s *ApplicationSession) SetAuthUserID(ctx context.Context, userID int) {
 	s.Put(ctx, appConstants.Auth_User_ID, userID)
 }
 
+// GetAuthLoggedInAt returns when the user of the session logged in, or the
+// zero time for sessions that predate it being recorded.
+func (s *ApplicationSession) GetAuthLoggedInAt(ctx context.Context) time.Time {
+	loggedInAt := s.GetInt64(ctx, appConstants.Auth_Logged_In_At)
+	if loggedInAt == 0 {
+		return time.Time{}
+	}
+	// Sessions from earlier releases hold seconds, which are far below any time in milliseconds
+	if loggedInAt < 1_000_000_000_000 {
+		return time.Unix(loggedInAt, 0)
+	}
+	return time.UnixMilli(loggedInAt)
+}
+func (s *ApplicationSession) SetAuthLoggedInAt(ctx context.Context, loggedInAt time.Time) {
+	s.Put(ctx, appConstants.Auth_Logged_In_At, loggedInAt.UnixMilli())
+}
+
+func (s *ApplicationSession) GetAuthUserRole(ctx context.Context) models.Role {
+	return models.Role(s.GetString(ctx, appConstants.Auth_User_Role))
+}
+func (s *ApplicationSession) SetAuthUserRole(ctx context.Context, role models.Role) {
+	s.Put(ctx, appConstants.Auth_User_Role, string(role))
+}
+
+// GetGuestCart returns the cart of an anonymous visitor, or an empty cart.
+func (s *ApplicationSession) GetGuestCart(ctx context.Context) models.GuestCart {
+	cart, ok := s.Get(ctx, appConstants.Guest_Cart).(models.GuestCart)
+	if !ok || cart == nil {
+		return models.GuestCart{}
+	}
+	return cart
+}
+func (s *ApplicationSession) RemoveGuestCart(ctx context.Context) {
+	s.Remove(ctx, appConstants.Guest_Cart)
+}
+func (s *ApplicationSession) SetGuestCart(ctx context.Context, cart models.GuestCart) {
+	s.Put(ctx, appConstants.Guest_Cart, cart)
+}
+
 func (s *ApplicationSession) GetCsrfToken(ctx context.Context) string {
 	token := s.GetString(ctx, appConstants.X_CSRF_Token)
 	if token == "" {
 		csrfToken, err := utils.GenerateRandomString(32)
 		if err != nil {
-			log.Printf("ERROR: ApplicationSession.GetCsrfToken - utils.GenerateRandomString: %v", err)
+			slog.ErrorContext(ctx, "ApplicationSession.GetCsrfToken - utils.GenerateRandomString", "error", err)
 		} else {
 			s.Put(ctx, appConstants.X_CSRF_Token, csrfToken)
 			token = csrfToken
@@ -73,3 +113,11 @@ func (s *ApplicationSession) PopFlashError(ctx context.Context) string {
 func (s *ApplicationSession) SetFlashError(ctx context.Context, err string) {
 	s.Put(ctx, appConstants.Flash_Error, err)
 }
+
+func (s *ApplicationSession) PopFlashMessage(ctx context.Context) string {
+	return s.PopString(ctx, appConstants.Flash_Message)
+}
+
+func (s *ApplicationSession) SetFlashMessage(ctx context.Context, message string) {
+	s.Put(ctx, appConstants.Flash_Message, message)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Go runs fn as a background worker. The context passed to fn is cancelled
+// when the application shuts down, and Shutdown waits for fn to return before
+// closing the database and Redis it may still be using.
+func (a *Application) Go(name string, fn func(ctx context.Context)) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		fn(a.workersCtx)
+		a.Logger.Info("Background worker stopped", "worker", name)
+	}()
+}
+
+// Shutdown releases the application's resources once the HTTP server has
+// stopped serving requests: first the background workers, then the database,
+// the Redis pool that backs sessions and finally the tracer, which exports the
+// spans it still holds. Workers still running when
+// ctx expires are abandoned, but the connections are closed regardless.
+func (a *Application) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	// 1. Stop the background workers
+	a.Logger.Info("Stopping background workers")
+	a.stopWorkers()
+
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		a.Logger.Error("Background workers did not stop in time", "error", ctx.Err())
+		errs = append(errs, fmt.Errorf("stopping background workers: %w", ctx.Err()))
+	}
+
+	// 2. Close the database
+	if a.DB != nil {
+		a.Logger.Info("Closing database connections")
+		if err := a.DB.Close(); err != nil {
+			a.Logger.Error("Failed to close the database", "error", err)
+			errs = append(errs, fmt.Errorf("closing database: %w", err))
+		}
+	}
+
+	// 3. Close Redis
+	a.Logger.Info("Closing Redis connections")
+	if err := a.RedisPool.Close(); err != nil {
+		a.Logger.Error("Failed to close the Redis pool", "error", err)
+		errs = append(errs, fmt.Errorf("closing redis: %w", err))
+	}
+
+	// 4. Flush the spans of the last requests
+	if err := a.shutdownTracing(ctx); err != nil {
+		a.Logger.Error("Failed to flush traces", "error", err)
+		errs = append(errs, fmt.Errorf("flushing traces: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
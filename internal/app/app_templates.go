@@ -5,7 +5,7 @@ import "html/template"
 func NewApplicationTemplates() (*template.Template, error) {
 	var templates *template.Template // Initiate Template
 
-	templates, err := template.ParseFiles("./templates/index.html", "./templates/login.html", "./templates/register.html")
+	templates, err := template.ParseFiles("./templates/index.html", "./templates/login.html", "./templates/register.html", "./templates/orders.html", "./templates/order.html", "./templates/admin_products.html", "./templates/kitchen.html", "./templates/forgot_password.html", "./templates/reset_password.html")
 	if err != nil {
 		return nil, err
 	}
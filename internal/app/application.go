@@ -1,29 +1,48 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/alexedwards/scs/redisstore"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/gomodule/redigo/redis"
-	"github.com/joho/godotenv"
+
+	config "dessert-ordering-go-system/internal/config"
+	mailer "dessert-ordering-go-system/internal/mailer"
+	metrics "dessert-ordering-go-system/internal/metrics"
+	tracing "dessert-ordering-go-system/internal/tracing"
 )
 
 type Application struct {
 	DEBUG     bool
+	Config    *config.Config
 	DB        *sql.DB
 	JWT       *ApplicationJwt
-	Loggers   *ApplicationLoggers
+	Logger    *slog.Logger
+	Mailer    mailer.Mailer
+	Metrics   *metrics.Metrics
 	Models    *ApplicationModels
 	Services  *ApplicationServices
 	RedisPool *redis.Pool
 	Session   *ApplicationSession
 	Templates *template.Template
+
+	// Flushes the buffered spans and stops the tracer provider
+	shutdownTracing func(context.Context) error
+
+	// Set once a shutdown signal arrives, see BeginShutdown
+	shuttingDown atomic.Bool
+
+	// Background workers started with Go, stopped by Shutdown
+	workers     sync.WaitGroup
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
 }
 
 // Render Template Helper Function
@@ -37,71 +56,106 @@ func (a *Application) RenderHtmlTemplate(w http.ResponseWriter, templateName str
 	err := a.Templates.ExecuteTemplate(w, templateName, data)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		log.Printf("Error rendering template %s: %v", templateName, err)
+		a.Logger.Error("Error rendering template", "template", templateName, "error", err)
 	}
 }
 
-func NewApplication() *Application {
-	// Initiate loggers
-	loggers := NewApplicationLoggers()
-
-	err := godotenv.Load()
-	if err != nil {
-		loggers.Info.Printf("Warning: Could not load .env file: %v. Assuming environment variables are set externally.", err)
+// NewApplication connects to Redis and the database and builds the shared
+// resources of the application from a validated configuration.
+func NewApplication(cfg *config.Config) *Application {
+	// Initiate the logger
+	logger := NewApplicationLogger(cfg.Log)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
 	}
 
-	debug := false
-	if debugEnv := os.Getenv("DEBUG"); debugEnv != "" {
-		parsedBool, err := strconv.ParseBool(debugEnv)
-		if err != nil {
-			loggers.Error.Fatalf("error: DEBUG value must be a boolean. i.e. true of false - %v", parsedBool)
-		} else {
-			debug = parsedBool
-		}
-	} else {
-		loggers.Info.Printf("Warning: DEBUG config not set in environment variables. Default to false")
+	// Record traces before anything that creates spans is built
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Error setting up tracing", err)
 	}
 
 	templates, err := NewApplicationTemplates()
 	if err != nil {
-		loggers.Error.Fatalf("Error parsing templates: %v", err)
+		fatal("Error parsing templates", err)
 	}
 
 	// Initialize Redigo Redis Pool instead of go-redis client
-	redisPool, err := openRedisPool()
+	redisPool, err := openRedisPool(cfg.Redis)
 	if err != nil {
-		loggers.Error.Fatalf("could not connect to Redis: %v", err)
+		fatal("Could not connect to Redis", err)
 	} else {
-		loggers.Info.Println("Successfully connected to Redis.")
+		logger.Info("Successfully connected to Redis")
 	}
 
 	// Initialize session manager
-	sessionManager := openSession(loggers, redisstore.New(redisPool))
+	sessionManager := openSession(cfg.Session, tracing.SessionStore(redisstore.New(redisPool), cfg.Redis.Addr))
 	session := NewApplicationSession(sessionManager)
 
-	// Initialize JWT
-	appJwt := NewApplicationJwt(loggers)
+	// Emails such as password reset links go through the MAIL_TRANSPORT
+	appMailer := mailer.New(cfg.Mail, logger)
 
-	// Open a database connection
-	db, err := openDB(os.Getenv("DSN"))
+	// Initialize JWT
+	appJwt, err := NewApplicationJwt(cfg.JWT)
 	if err != nil {
-		loggers.Error.Fatalf("Error opening or connecting to database: %v", err)
+		fatal("Error loading JWT signing keys", err)
 	}
-	loggers.Info.Println("Successfully connected to Database!")
 
-	models := NewApplicationModels(db)
-	services := NewApplicationServices(models, appJwt)
+	// Open a database connection, unless everything is kept in memory
+	var (
+		db     *sql.DB
+		models *ApplicationModels
+	)
+	driver := cfg.Database.Driver
+	if driver == config.DriverMemory {
+		logger.Warn("DB_DRIVER is memory. Nothing is persisted once the server stops")
+		models = NewMemoryApplicationModels()
+	} else {
+		db, err = OpenDB(driver, cfg.Database.DSN)
+		if err != nil {
+			fatal("Error opening or connecting to database", err)
+		}
+		logger.Info("Successfully connected to Database", "driver", driver)
+
+		models = NewApplicationModels(db, driver, cfg.Database.QueryTimeout)
+	}
+	// Revoked JWTs and sessions are remembered for as long as either can live
+	denylist := NewRedisTokenDenylist(redisPool, max(cfg.JWT.Expiration, cfg.Session.Lifetime))
+
+	models = models.WithTracing()
+	services := NewApplicationServices(models, appJwt, denylist, appMailer, cfg)
+
+	// Collect metrics about requests, connection pools and business events
+	appMetrics := metrics.New()
+	appMetrics.RegisterRedisPool(redisPool)
+	if db != nil {
+		appMetrics.RegisterDB(db, driver)
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
 
 	a := &Application{
-		DEBUG:     debug,
+		DEBUG:     cfg.Debug,
+		Config:    cfg,
 		DB:        db,
 		JWT:       appJwt,
-		Loggers:   loggers,
+		Logger:    logger,
+		Mailer:    appMailer,
+		Metrics:   appMetrics,
 		Models:    models,
 		Services:  services,
 		RedisPool: redisPool,
 		Session:   session,
 		Templates: templates,
+
+		shutdownTracing: shutdownTracing,
+		workersCtx:      workersCtx,
+		stopWorkers:     stopWorkers,
+	}
+
+	if appJwt.Keys != nil && appJwt.KeyRotation > 0 {
+		a.Go("jwt key rotation", a.rotateJwtKeys)
 	}
 	return a
 }
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	config "dessert-ordering-go-system/internal/config"
+)
+
+const configUsage = `usage: config print
+
+  print   show the resolved configuration as YAML, with secrets redacted`
+
+// Config runs the "config" subcommand. The configuration is printed even when
+// it is invalid, followed by every problem found, so that it can be fixed in one go.
+func Config(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	if args[0] != "print" {
+		return fmt.Errorf("unknown config command %q\n%s", args[0], configUsage)
+	}
+
+	cfg, loadErr := config.Load()
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	encoder.Close()
+
+	return loadErr
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+
+	app "dessert-ordering-go-system/internal/app"
+	config "dessert-ordering-go-system/internal/config"
+	logging "dessert-ordering-go-system/internal/logging"
+)
+
+// Run executes the subcommand named by args[0]. It reports false when args do
+// not name a subcommand, in which case the caller should start the web server.
+func Run(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "migrate":
+		return true, Migrate(args[1:])
+	case "seed":
+		return true, Seed(args[1:])
+	case "config":
+		return true, Config(args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// openDatabase connects to the configured database without starting the rest
+// of the application (Redis, sessions, templates). Only the database settings
+// have to be valid.
+func openDatabase() (*sql.DB, *config.Config, error) {
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Model errors are logged through slog, in the same format as the server's
+	slog.SetDefault(logging.New(os.Stderr, cfg.Log.Format, cfg.Log.SlogLevel()))
+
+	if cfg.Database.Driver == config.DriverMemory {
+		return nil, nil, fmt.Errorf("DB_DRIVER is memory: there is no database to run commands against, use mysql or sqlite")
+	}
+
+	db, err := app.OpenDB(cfg.Database.Driver, cfg.Database.DSN)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening or connecting to database: %w", err)
+	}
+	return db, cfg, nil
+}
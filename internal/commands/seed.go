@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+)
+
+// DefaultCatalogFile is the catalog loaded when seed is run without --file.
+const DefaultCatalogFile = "data/catalog.json"
+
+// Catalog is the contents of a catalog file.
+type Catalog struct {
+	Products []CatalogProduct `json:"products" yaml:"products"`
+}
+
+// CatalogProduct is a single product of a catalog file. Images are listed in
+// display order: mobile, tablet, desktop.
+type CatalogProduct struct {
+	Title       string   `json:"title" yaml:"title"`
+	Category    string   `json:"category" yaml:"category"`
+	Description string   `json:"description" yaml:"description"`
+	Price       string   `json:"price" yaml:"price"`
+	Stock       int      `json:"stock" yaml:"stock"`
+	Thumbnail   string   `json:"thumbnail" yaml:"thumbnail"`
+	Images      []string `json:"images" yaml:"images"`
+}
+
+// LoadCatalog reads a JSON or YAML catalog, picking the format from the file extension.
+func LoadCatalog(path string) (*Catalog, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	catalog := &Catalog{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(contents, catalog)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, catalog)
+	default:
+		return nil, fmt.Errorf("unsupported catalog format %q: use .json, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+	}
+
+	return catalog, nil
+}
+
+// ToInput validates the product and converts it into model input.
+func (p *CatalogProduct) ToInput() (*models.ProductInput, error) {
+	if p.Title == "" || p.Category == "" || p.Thumbnail == "" {
+		return nil, errors.New("title, category and thumbnail are required")
+	}
+	if p.Stock < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+
+	price, err := money.Parse(p.Price, money.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if price.Amount <= 0 {
+		return nil, errors.New("price must be positive")
+	}
+
+	return &models.ProductInput{
+		Title:       p.Title,
+		Category:    p.Category,
+		Description: p.Description,
+		Price:       price,
+		Thumbnail:   p.Thumbnail,
+		Stock:       p.Stock,
+	}, nil
+}
+
+// Seed runs the "seed" subcommand.
+func Seed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := flags.String("file", DefaultCatalogFile, "path to a JSON or YAML catalog file")
+	reset := flags.Bool("reset", false, "delete every product, cart item and order before seeding (requires DEBUG=true)")
+	flags.SetOutput(io.Discard)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%v\nusage: seed [--file %s] [--reset]", err, DefaultCatalogFile)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q\nusage: seed [--file %s] [--reset]", flags.Arg(0), DefaultCatalogFile)
+	}
+
+	// Check the whole catalog before touching the database
+	catalog, err := LoadCatalog(*file)
+	if err != nil {
+		return err
+	}
+
+	inputs := make([]*models.ProductInput, len(catalog.Products))
+	for i := range catalog.Products {
+		inputs[i], err = catalog.Products[i].ToInput()
+		if err != nil {
+			return fmt.Errorf("invalid product #%d %q in %s: %w", i+1, catalog.Products[i].Title, *file, err)
+		}
+	}
+
+	db, cfg, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Seeding is not bound to a request, so each model call is only limited by the query timeout
+	ctx := context.Background()
+	productModel := &models.ProductModel{
+		DB:           db,
+		Dialect:      models.Dialect(cfg.Database.Driver),
+		QueryTimeout: cfg.Database.QueryTimeout,
+	}
+
+	if *reset {
+		if !cfg.Debug {
+			return errors.New("refusing to reset a database outside of DEBUG mode: set DEBUG=true to reset a development database")
+		}
+
+		err := productModel.ResetCatalog(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to reset the catalog: %w", err)
+		}
+		fmt.Println("Deleted every product, cart item and order.")
+	}
+
+	var created, updated int
+	for i, input := range inputs {
+		product, isNew, err := productModel.UpsertProduct(ctx, input, catalog.Products[i].Images)
+		if err != nil {
+			if models.IsDuplicateEntryError(err) {
+				err = models.ErrDuplicateRecord
+			}
+			return fmt.Errorf("failed to seed %q: %w", input.Title, err)
+		}
+
+		if isNew {
+			created++
+			fmt.Printf("Created #%d %s\n", product.ID, product.Title)
+		} else {
+			updated++
+			fmt.Printf("Updated #%d %s\n", product.ID, product.Title)
+		}
+	}
+
+	fmt.Printf("Seeded %d products from %s (%d created, %d updated).\n", len(inputs), *file, created, updated)
+	return nil
+}
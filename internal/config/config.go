@@ -0,0 +1,586 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Database drivers accepted by DB_DRIVER.
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+// JWT signing algorithms accepted by JWT_ALGORITHM.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Tracing exporters accepted by TRACING_EXPORTER.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Mail transports accepted by MAIL_TRANSPORT.
+const (
+	TransportSMTP = "smtp"
+	TransportLog  = "log"
+	TransportFile = "file"
+)
+
+// redacted replaces secrets in the output of Redacted.
+const redacted = "********"
+
+// Config holds every setting of the application. Values are resolved in this
+// order, later sources winning: the defaults below, the YAML or JSON file named
+// by CONFIG_FILE and finally environment variables, including those set in .env.
+type Config struct {
+	Debug    bool           `yaml:"debug"`
+	Log      LogConfig      `yaml:"log"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Redis    RedisConfig    `yaml:"redis"`
+	Session  SessionConfig  `yaml:"session"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Mail     MailConfig     `yaml:"mail"`
+	Account  AccountConfig  `yaml:"account"`
+}
+
+type LogConfig struct {
+	// Level is the lowest level written: debug, info, warn or error
+	Level string `yaml:"level"`
+	// Format is json for log pipelines or text for reading in a terminal
+	Format string `yaml:"format"`
+}
+
+// SlogLevel returns Level as a slog.Level, or slog.LevelInfo if it is invalid.
+func (l LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay keeps serving requests, with /readyz failing, for this
+	// long after a shutdown signal so load balancers can take the instance out.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+}
+
+type DatabaseConfig struct {
+	Driver       string        `yaml:"driver"`
+	DSN          string        `yaml:"dsn"`
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+type RedisConfig struct {
+	Addr        string        `yaml:"addr"`
+	Password    string        `yaml:"password"`
+	MaxIdle     int           `yaml:"max_idle"`
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+type SessionConfig struct {
+	Lifetime      time.Duration `yaml:"lifetime"`
+	SecureCookies bool          `yaml:"secure_cookies"`
+}
+
+type JWTConfig struct {
+	// Algorithm signs tokens: HS256 with SecretKey, or RS256 or EdDSA with
+	// the keys in KeysDir
+	Algorithm  string        `yaml:"algorithm"`
+	SecretKey  string        `yaml:"secret_key"`
+	Expiration time.Duration `yaml:"expiration"`
+	// RefreshExpiration is how long an unused refresh token stays valid
+	RefreshExpiration time.Duration `yaml:"refresh_expiration"`
+	// KeysDir holds the private keys of RS256 and EdDSA, one PEM file per kid
+	KeysDir string `yaml:"keys_dir"`
+	// KeyRotation is how often a new signing key is made; 0 never rotates
+	KeyRotation time.Duration `yaml:"key_rotation"`
+}
+
+// IsAsymmetric reports whether tokens are signed with the keys in KeysDir.
+func (j JWTConfig) IsAsymmetric() bool {
+	return j.Algorithm != AlgorithmHS256
+}
+
+type MetricsConfig struct {
+	// Token, when set, must be sent as a bearer token to read /metrics
+	Token string `yaml:"token"`
+	// Addr, when set, serves /metrics on its own listener instead of the main one.
+	// One of Token or Addr is required unless Debug is set.
+	Addr string `yaml:"addr"`
+}
+
+type TracingConfig struct {
+	// Exporter is where spans are sent: none, otlp, stdout or file
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the host:port of the OTLP/HTTP collector. When empty the
+	// standard OTEL_EXPORTER_OTLP_* variables are used.
+	Endpoint string `yaml:"endpoint"`
+	// File receives the spans, one JSON document each, when Exporter is file
+	File string `yaml:"file"`
+}
+
+type MailConfig struct {
+	// Transport delivers the emails: smtp sends them through SMTP, log writes
+	// them to the application log and file appends them to File. The last two
+	// are for development and need Debug.
+	Transport string     `yaml:"transport"`
+	SMTP      SMTPConfig `yaml:"smtp"`
+	File      string     `yaml:"file"`
+	From      string     `yaml:"from"`
+	// BaseURL is where the links in emails point, e.g. https://shop.example.com
+	BaseURL string `yaml:"base_url"`
+}
+
+type SMTPConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// Username and Password log in to the server, which then has to offer
+	// STARTTLS. Both empty sends without logging in.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type AccountConfig struct {
+	// PasswordResetExpiration is how long a password reset link works
+	PasswordResetExpiration time.Duration `yaml:"password_reset_expiration"`
+	// PasswordResetCooldown is how long after a reset link another one can be
+	// emailed to the same account
+	PasswordResetCooldown time.Duration `yaml:"password_reset_cooldown"`
+	// EmailVerificationExpiration is how long an email verification link works
+	EmailVerificationExpiration time.Duration `yaml:"email_verification_expiration"`
+	// VerificationResendCooldown is how long a user waits before asking for
+	// another verification email
+	VerificationResendCooldown time.Duration `yaml:"verification_resend_cooldown"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() *Config {
+	return &Config{
+		Debug: false,
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:       DriverMySQL,
+			QueryTimeout: 5 * time.Second,
+		},
+		Redis: RedisConfig{
+			MaxIdle:     10,
+			IdleTimeout: 240 * time.Second,
+		},
+		Session: SessionConfig{
+			Lifetime:      12 * time.Hour,
+			SecureCookies: true,
+		},
+		JWT: JWTConfig{
+			Algorithm:         AlgorithmHS256,
+			Expiration:        1 * time.Hour,
+			RefreshExpiration: 30 * 24 * time.Hour,
+			KeysDir:           "jwt-keys",
+			KeyRotation:       30 * 24 * time.Hour,
+		},
+		Tracing: TracingConfig{
+			Exporter: ExporterNone,
+			File:     "traces.json",
+		},
+		Mail: MailConfig{
+			Transport: TransportSMTP,
+			SMTP:      SMTPConfig{Port: 587},
+			File:      "mail.log",
+			From:      "Dessert Ordering <no-reply@localhost>",
+			BaseURL:   "http://localhost:8080",
+		},
+		Account: AccountConfig{
+			PasswordResetExpiration:     1 * time.Hour,
+			PasswordResetCooldown:       1 * time.Minute,
+			EmailVerificationExpiration: 48 * time.Hour,
+			VerificationResendCooldown:  1 * time.Minute,
+		},
+	}
+}
+
+// Load reads the configuration for the web server and validates it. The
+// returned *Error lists every problem found, not only the first one.
+func Load() (*Config, error) {
+	c, problems := load()
+	problems = append(problems, c.validate()...)
+
+	if len(problems) > 0 {
+		return c, &Error{Problems: problems}
+	}
+	return c, nil
+}
+
+// LoadDatabase reads the configuration like Load but only validates the
+// database settings, for commands such as migrate that never start the server.
+func LoadDatabase() (*Config, error) {
+	c, problems := load()
+	problems = append(problems, c.Database.validate()...)
+
+	if len(problems) > 0 {
+		return c, &Error{Problems: problems}
+	}
+	return c, nil
+}
+
+// load resolves the configuration from every source and returns the values
+// that could not be parsed.
+func load() (*Config, []string) {
+	c := Default()
+	var problems []string
+
+	// 1. The .env file, which never overrides variables that are already set
+	_ = godotenv.Load()
+
+	// 2. The config file, if one is named
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := c.readFile(path); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	// 3. The environment
+	env := &envReader{}
+
+	env.bool("DEBUG", &c.Debug)
+
+	env.string("LOG_LEVEL", &c.Log.Level)
+	env.string("LOG_FORMAT", &c.Log.Format)
+	c.Log.Format = strings.ToLower(c.Log.Format)
+
+	env.string("SERVER_ADDR", &c.Server.Addr)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.duration("SHUTDOWN_DELAY", &c.Server.ShutdownDelay)
+
+	env.string("DB_DRIVER", &c.Database.Driver)
+	env.string("DSN", &c.Database.DSN)
+	env.duration("DB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+	c.Database.Driver = strings.ToLower(strings.TrimSpace(c.Database.Driver))
+
+	env.string("REDIS_ADDR", &c.Redis.Addr)
+	env.string("REDIS_PASSWORD", &c.Redis.Password)
+	env.int("REDIS_MAX_IDLE", &c.Redis.MaxIdle)
+	env.duration("REDIS_IDLE_TIMEOUT", &c.Redis.IdleTimeout)
+
+	env.duration("SESSION_LIFETIME", &c.Session.Lifetime)
+	env.bool("SECURE_COOKIES", &c.Session.SecureCookies)
+
+	env.string("JWT_ALGORITHM", &c.JWT.Algorithm)
+	env.string("JWT_SECRET_KEY", &c.JWT.SecretKey)
+	env.duration("JWT_EXPIRATION", &c.JWT.Expiration)
+	env.duration("JWT_REFRESH_EXPIRATION", &c.JWT.RefreshExpiration)
+	env.string("JWT_KEYS_DIR", &c.JWT.KeysDir)
+	env.duration("JWT_KEY_ROTATION", &c.JWT.KeyRotation)
+
+	env.string("METRICS_TOKEN", &c.Metrics.Token)
+	env.string("METRICS_ADDR", &c.Metrics.Addr)
+
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_ENDPOINT", &c.Tracing.Endpoint)
+	env.string("TRACING_FILE", &c.Tracing.File)
+	c.Tracing.Exporter = strings.ToLower(strings.TrimSpace(c.Tracing.Exporter))
+
+	env.string("MAIL_TRANSPORT", &c.Mail.Transport)
+	env.string("SMTP_HOST", &c.Mail.SMTP.Host)
+	env.int("SMTP_PORT", &c.Mail.SMTP.Port)
+	env.string("SMTP_USERNAME", &c.Mail.SMTP.Username)
+	env.string("SMTP_PASSWORD", &c.Mail.SMTP.Password)
+	env.string("MAIL_FILE", &c.Mail.File)
+	env.string("MAIL_FROM", &c.Mail.From)
+	env.string("MAIL_BASE_URL", &c.Mail.BaseURL)
+	c.Mail.Transport = strings.ToLower(strings.TrimSpace(c.Mail.Transport))
+	c.Mail.BaseURL = strings.TrimRight(c.Mail.BaseURL, "/")
+
+	env.duration("PASSWORD_RESET_EXPIRATION", &c.Account.PasswordResetExpiration)
+	env.duration("PASSWORD_RESET_COOLDOWN", &c.Account.PasswordResetCooldown)
+	env.duration("EMAIL_VERIFICATION_EXPIRATION", &c.Account.EmailVerificationExpiration)
+	env.duration("VERIFICATION_RESEND_COOLDOWN", &c.Account.VerificationResendCooldown)
+
+	return c, append(problems, env.problems...)
+}
+
+// readFile overlays the settings found in a YAML or JSON file onto c. Settings
+// the file leaves out keep their current value.
+func (c *Config) readFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("CONFIG_FILE %s: %v", path, err)
+	}
+	return nil
+}
+
+// validate checks every section and returns all problems found.
+func (c *Config) validate() []string {
+	var problems []string
+
+	problems = append(problems, c.Log.validate()...)
+	problems = append(problems, c.Server.validate()...)
+	problems = append(problems, c.Database.validate()...)
+	problems = append(problems, c.Redis.validate()...)
+	problems = append(problems, c.Session.validate()...)
+	problems = append(problems, c.JWT.validate()...)
+	problems = append(problems, c.Tracing.validate()...)
+	problems = append(problems, c.Mail.validate(c.Debug)...)
+	problems = append(problems, c.Account.validate()...)
+
+	if c.Metrics.Addr != "" && c.Metrics.Addr == c.Server.Addr {
+		problems = append(problems, "METRICS_ADDR must differ from SERVER_ADDR, leave it empty to serve /metrics on the main listener")
+	}
+	// Route names, pool stats and failed logins must not be public
+	if !c.Debug && c.Metrics.Addr == "" && c.Metrics.Token == "" {
+		problems = append(problems, "METRICS_TOKEN or METRICS_ADDR must be set, /metrics would be public on the main listener (allowed with DEBUG=true)")
+	}
+
+	return problems
+}
+
+func (l LogConfig) validate() []string {
+	var problems []string
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL must be debug, info, warn or error, got %q", l.Level))
+	}
+	if l.Format != "json" && l.Format != "text" {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT must be json or text, got %q", l.Format))
+	}
+
+	return problems
+}
+
+func (s ServerConfig) validate() []string {
+	var problems []string
+
+	if s.Addr == "" {
+		problems = append(problems, "SERVER_ADDR must be set, e.g. :8080")
+	}
+	problems = append(problems, positive("SERVER_READ_TIMEOUT", s.ReadTimeout)...)
+	problems = append(problems, positive("SERVER_WRITE_TIMEOUT", s.WriteTimeout)...)
+	problems = append(problems, positive("SERVER_IDLE_TIMEOUT", s.IdleTimeout)...)
+	problems = append(problems, positive("SHUTDOWN_TIMEOUT", s.ShutdownTimeout)...)
+	if s.ShutdownDelay < 0 {
+		problems = append(problems, fmt.Sprintf("SHUTDOWN_DELAY must not be negative, got %s", s.ShutdownDelay))
+	}
+
+	return problems
+}
+
+func (d DatabaseConfig) validate() []string {
+	var problems []string
+
+	switch d.Driver {
+	case DriverMySQL, DriverSQLite:
+		if d.DSN == "" {
+			problems = append(problems, fmt.Sprintf("DSN must be set when DB_DRIVER is %s", d.Driver))
+		}
+	case DriverMemory:
+	default:
+		problems = append(problems, fmt.Sprintf("DB_DRIVER must be mysql, sqlite or memory, got %q", d.Driver))
+	}
+	problems = append(problems, positive("DB_QUERY_TIMEOUT", d.QueryTimeout)...)
+
+	return problems
+}
+
+func (r RedisConfig) validate() []string {
+	var problems []string
+
+	if r.Addr == "" {
+		problems = append(problems, "REDIS_ADDR must be set, e.g. localhost:6379")
+	}
+	if r.MaxIdle < 1 {
+		problems = append(problems, fmt.Sprintf("REDIS_MAX_IDLE must be at least 1, got %d", r.MaxIdle))
+	}
+	problems = append(problems, positive("REDIS_IDLE_TIMEOUT", r.IdleTimeout)...)
+
+	return problems
+}
+
+func (s SessionConfig) validate() []string {
+	return positive("SESSION_LIFETIME", s.Lifetime)
+}
+
+func (j JWTConfig) validate() []string {
+	var problems []string
+
+	switch j.Algorithm {
+	case AlgorithmHS256:
+		if j.SecretKey == "" {
+			problems = append(problems, "JWT_SECRET_KEY must be set when JWT_ALGORITHM is HS256")
+		}
+	case AlgorithmRS256, AlgorithmEdDSA:
+		if j.KeysDir == "" {
+			problems = append(problems, fmt.Sprintf("JWT_KEYS_DIR must be set when JWT_ALGORITHM is %s", j.Algorithm))
+		}
+		if j.KeyRotation < 0 {
+			problems = append(problems, fmt.Sprintf("JWT_KEY_ROTATION must not be negative, got %s", j.KeyRotation))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("JWT_ALGORITHM must be HS256, RS256 or EdDSA, got %q", j.Algorithm))
+	}
+	problems = append(problems, positive("JWT_EXPIRATION", j.Expiration)...)
+	problems = append(problems, positive("JWT_REFRESH_EXPIRATION", j.RefreshExpiration)...)
+
+	return problems
+}
+
+func (t TracingConfig) validate() []string {
+	var problems []string
+
+	switch t.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+	case ExporterFile:
+		if t.File == "" {
+			problems = append(problems, "TRACING_FILE must be set when TRACING_EXPORTER is file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER must be none, otlp, stdout or file, got %q", t.Exporter))
+	}
+
+	return problems
+}
+
+func (m MailConfig) validate(debug bool) []string {
+	var problems []string
+
+	switch m.Transport {
+	case TransportSMTP:
+		if m.SMTP.Host == "" {
+			problems = append(problems, "SMTP_HOST must be set when MAIL_TRANSPORT is smtp")
+		}
+		if m.SMTP.Port < 1 || m.SMTP.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("SMTP_PORT must be between 1 and 65535, got %d", m.SMTP.Port))
+		}
+		if (m.SMTP.Username == "") != (m.SMTP.Password == "") {
+			problems = append(problems, "SMTP_USERNAME and SMTP_PASSWORD must be set together")
+		}
+	case TransportLog:
+		// Emails carry password reset links, which must not reach a production log pipeline
+		if !debug {
+			problems = append(problems, "MAIL_TRANSPORT=log writes account links to the log and needs DEBUG=true")
+		}
+	case TransportFile:
+		// Users never receive these emails, so they could not verify their address
+		if !debug {
+			problems = append(problems, "MAIL_TRANSPORT=file does not deliver emails and needs DEBUG=true")
+		}
+		if m.File == "" {
+			problems = append(problems, "MAIL_FILE must be set when MAIL_TRANSPORT is file")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("MAIL_TRANSPORT must be smtp, log or file, got %q", m.Transport))
+	}
+	if m.From == "" {
+		problems = append(problems, "MAIL_FROM must be set, e.g. no-reply@example.com")
+	}
+	if u, err := url.Parse(m.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("MAIL_BASE_URL must be an http or https URL, got %q", m.BaseURL))
+	}
+
+	return problems
+}
+
+func (a AccountConfig) validate() []string {
+	var problems []string
+
+	problems = append(problems, positive("PASSWORD_RESET_EXPIRATION", a.PasswordResetExpiration)...)
+	problems = append(problems, positive("EMAIL_VERIFICATION_EXPIRATION", a.EmailVerificationExpiration)...)
+	if a.PasswordResetCooldown < 0 {
+		problems = append(problems, fmt.Sprintf("PASSWORD_RESET_COOLDOWN must not be negative, got %s", a.PasswordResetCooldown))
+	}
+	if a.VerificationResendCooldown < 0 {
+		problems = append(problems, fmt.Sprintf("VERIFICATION_RESEND_COOLDOWN must not be negative, got %s", a.VerificationResendCooldown))
+	}
+
+	return problems
+}
+
+func positive(key string, d time.Duration) []string {
+	if d <= 0 {
+		return []string{fmt.Sprintf("%s must be a positive duration such as 5s, got %s", key, d)}
+	}
+	return nil
+}
+
+// redactDSN masks the password of a MySQL DSN (user:password@tcp(...)/db). It
+// splits the DSN like the driver does, at the last @ before the last /, so that
+// a password containing @ is masked whole.
+func redactDSN(dsn string) string {
+	end := len(dsn)
+	if slash := strings.LastIndex(dsn, "/"); slash >= 0 {
+		end = slash
+	}
+	at := strings.LastIndex(dsn[:end], "@")
+	if at < 0 {
+		return dsn
+	}
+
+	user, _, hasPassword := strings.Cut(dsn[:at], ":")
+	if !hasPassword {
+		return dsn
+	}
+	return user + ":" + redacted + dsn[at:]
+}
+
+// Redacted returns a copy of c with passwords and keys masked, safe to print.
+func (c *Config) Redacted() *Config {
+	safe := *c
+
+	safe.Database.DSN = redactDSN(c.Database.DSN)
+	if safe.Redis.Password != "" {
+		safe.Redis.Password = redacted
+	}
+	if safe.JWT.SecretKey != "" {
+		safe.JWT.SecretKey = redacted
+	}
+	if safe.Mail.SMTP.Password != "" {
+		safe.Mail.SMTP.Password = redacted
+	}
+	if safe.Metrics.Token != "" {
+		safe.Metrics.Token = redacted
+	}
+	return &safe
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// valid returns a configuration that passes validate.
+func valid() *Config {
+	c := Default()
+	c.Database.DSN = "app:secret@tcp(db:3306)/shop"
+	c.Redis.Addr = "localhost:6379"
+	c.JWT.SecretKey = "jwt-secret"
+	c.Mail.SMTP.Host = "smtp.example.com"
+	c.Metrics.Token = "metrics-secret"
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string // the start of every problem expected, in order
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{
+			name: "every problem is listed",
+			modify: func(c *Config) {
+				c.Log.Level = "loud"
+				c.Server.Addr = ""
+				c.Database.DSN = ""
+				c.Redis.Addr = ""
+				c.JWT.SecretKey = ""
+				c.Account.PasswordResetCooldown = -time.Second
+			},
+			want: []string{"LOG_LEVEL", "SERVER_ADDR", "DSN", "REDIS_ADDR", "JWT_SECRET_KEY", "PASSWORD_RESET_COOLDOWN"},
+		},
+		{
+			name:   "memory needs no DSN",
+			modify: func(c *Config) { c.Database.Driver, c.Database.DSN = DriverMemory, "" },
+		},
+		{
+			name:   "unknown driver",
+			modify: func(c *Config) { c.Database.Driver = "postgres" },
+			want:   []string{"DB_DRIVER"},
+		},
+		{
+			name:   "asymmetric JWT needs no secret",
+			modify: func(c *Config) { c.JWT.Algorithm, c.JWT.SecretKey = AlgorithmEdDSA, "" },
+		},
+		{
+			name:   "smtp without host",
+			modify: func(c *Config) { c.Mail.SMTP.Host = "" },
+			want:   []string{"SMTP_HOST"},
+		},
+		{
+			name:   "smtp username without password",
+			modify: func(c *Config) { c.Mail.SMTP.Username = "mailer" },
+			want:   []string{"SMTP_USERNAME and SMTP_PASSWORD"},
+		},
+		{
+			name:   "log transport without debug",
+			modify: func(c *Config) { c.Mail.Transport = TransportLog },
+			want:   []string{"MAIL_TRANSPORT=log"},
+		},
+		{
+			name:   "log transport with debug",
+			modify: func(c *Config) { c.Mail.Transport, c.Debug = TransportLog, true },
+		},
+		{
+			name:   "file transport without debug",
+			modify: func(c *Config) { c.Mail.Transport = TransportFile },
+			want:   []string{"MAIL_TRANSPORT=file"},
+		},
+		{
+			name:   "file transport with debug",
+			modify: func(c *Config) { c.Mail.Transport, c.Debug = TransportFile, true },
+		},
+		{
+			name:   "public metrics",
+			modify: func(c *Config) { c.Metrics.Token = "" },
+			want:   []string{"METRICS_TOKEN or METRICS_ADDR"},
+		},
+		{
+			name:   "metrics on their own listener",
+			modify: func(c *Config) { c.Metrics.Token, c.Metrics.Addr = "", ":9090" },
+		},
+		{
+			name:   "public metrics with debug",
+			modify: func(c *Config) { c.Metrics.Token, c.Debug = "", true },
+		},
+		{
+			name:   "metrics on the main listener address",
+			modify: func(c *Config) { c.Metrics.Addr = c.Server.Addr },
+			want:   []string{"METRICS_ADDR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(c)
+
+			problems := c.validate()
+			if len(problems) != len(tt.want) {
+				t.Fatalf("validate() = %q, want %d problems starting with %q", problems, len(tt.want), tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(problems[i], want) {
+					t.Errorf("problem %d = %q, want it to start with %q", i, problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "server:\n  addr: \":9000\"\n  read_timeout: 7s\nredis:\n  addr: file-redis:6379\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SERVER_ADDR", ":9100")
+	// Empty variables are ignored, so these cannot leak in from the shell
+	for _, key := range []string{"SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT", "REDIS_ADDR"} {
+		t.Setenv(key, "")
+	}
+
+	c, problems := load()
+	if len(problems) > 0 {
+		t.Fatalf("load() problems = %q", problems)
+	}
+
+	tests := []struct {
+		setting   string
+		got, want any
+	}{
+		{setting: "default", got: c.Server.WriteTimeout, want: Default().Server.WriteTimeout},
+		{setting: "file over default", got: c.Server.ReadTimeout, want: 7 * time.Second},
+		{setting: "file over default", got: c.Redis.Addr, want: "file-redis:6379"},
+		{setting: "env over file", got: c.Server.Addr, want: ":9100"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadReportsEveryBadValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("unknown: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DEBUG", "maybe")
+	t.Setenv("SMTP_PORT", "smtp")
+
+	_, problems := load()
+	for _, want := range []string{"CONFIG_FILE", "DEBUG", "SMTP_PORT"} {
+		if !slices.ContainsFunc(problems, func(p string) bool { return strings.HasPrefix(p, want) }) {
+			t.Errorf("load() problems = %q, want one about %s", problems, want)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{dsn: "app:secret@tcp(db:3306)/shop", want: "app:" + redacted + "@tcp(db:3306)/shop"},
+		{dsn: "app:p@ss@tcp(db)/shop", want: "app:" + redacted + "@tcp(db)/shop"},
+		{dsn: "app:p:w@tcp(db)/shop?parseTime=true", want: "app:" + redacted + "@tcp(db)/shop?parseTime=true"},
+		{dsn: "app:@tcp(db)/shop", want: "app:" + redacted + "@tcp(db)/shop"},
+		{dsn: "app@tcp(db)/shop", want: "app@tcp(db)/shop"},
+		{dsn: "file:desserts.db?_pragma=foreign_keys(1)", want: "file:desserts.db?_pragma=foreign_keys(1)"},
+		{dsn: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		c := valid()
+		c.Database.DSN = tt.dsn
+		if got := c.Redacted().Database.DSN; got != tt.want {
+			t.Errorf("Redacted() DSN %q = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+
+	c := valid()
+	c.Redis.Password = "redis-secret"
+	c.Mail.SMTP.Password = "smtp-secret"
+	safe := c.Redacted()
+
+	for name, value := range map[string]string{
+		"REDIS_PASSWORD": safe.Redis.Password,
+		"JWT_SECRET_KEY": safe.JWT.SecretKey,
+		"SMTP_PASSWORD":  safe.Mail.SMTP.Password,
+		"METRICS_TOKEN":  safe.Metrics.Token,
+	} {
+		if value != redacted {
+			t.Errorf("Redacted() %s = %q, want %q", name, value, redacted)
+		}
+	}
+	if c.Database.DSN != "app:secret@tcp(db:3306)/shop" || c.Redis.Password != "redis-secret" {
+		t.Error("Redacted() changed the original config")
+	}
+}
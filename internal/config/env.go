@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envReader overrides settings with environment variables. A variable that is
+// unset or empty leaves the setting alone, and one that cannot be parsed is
+// recorded in problems so that every bad value is reported together.
+type envReader struct {
+	problems []string
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if value := os.Getenv(key); value != "" {
+		*dst = value
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be true or false, got %q", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envReader) int(key string, dst *int) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a whole number, got %q", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a duration such as 5s or 1h, got %q", key, value))
+		return
+	}
+	*dst = parsed
+}
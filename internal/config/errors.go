@@ -0,0 +1,12 @@
+package config
+
+import "strings"
+
+// Error reports every problem found while loading the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
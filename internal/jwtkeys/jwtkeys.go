@@ -0,0 +1,339 @@
+// Package jwtkeys keeps the asymmetric keys that sign and verify JWTs. Each key
+// is a PKCS #8 PEM file in one directory, named after its key ID (kid), which
+// starts with its creation time. A new key is published for ActivationDelay
+// before it signs anything; older keys keep verifying the tokens they signed
+// until those have expired.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms accepted by JWT_ALGORITHM besides HS256.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys.
+const rsaKeyBits = 2048
+
+// fileExtension is the extension of key files.
+const fileExtension = ".pem"
+
+// kidTimeLayout is the creation time at the start of every kid.
+const kidTimeLayout = "20060102T150405Z"
+
+// JWKSMaxAge is how long verifiers may cache the published keys.
+const JWKSMaxAge = 5 * time.Minute
+
+// ActivationDelay is how long a new key is only published before it starts
+// signing: long enough for verifiers to drop their cached JWKS and for other
+// instances sharing the directory to reload it.
+const ActivationDelay = JWKSMaxAge + time.Minute
+
+// unknownKidReloadInterval limits how often tokens with an unknown kid make
+// the keyring read the directory, so that forged tokens cannot keep it busy.
+const unknownKidReloadInterval = 10 * time.Second
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is one signing key of the keyring.
+type Key struct {
+	ID        string
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// Method returns the JWT signing method of the key.
+func (k *Key) Method() jwt.SigningMethod {
+	if _, ok := k.Private.(ed25519.PrivateKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// Public returns the key that verifies what k signed.
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// Keyring holds the keys found in a directory. It is safe for concurrent use.
+type Keyring struct {
+	dir       string
+	algorithm string
+
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	reloadedAt time.Time
+}
+
+// Open loads the keys of dir, creating the directory and a first key for
+// algorithm when there are none. Keys of another algorithm are ignored.
+func Open(dir, algorithm string) (*Keyring, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q: use RS256 or EdDSA", algorithm)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("jwt keys directory: %w", err)
+	}
+
+	k := &Keyring{dir: dir, algorithm: algorithm}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	if k.SigningKey() == nil {
+		if _, err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Reload reads the directory again, picking up keys that another instance
+// sharing it has created or removed.
+func (k *Keyring) Reload() error {
+	entries, err := os.ReadDir(k.dir)
+	if err != nil {
+		return fmt.Errorf("jwt keys directory: %w", err)
+	}
+
+	keys := make(map[string]*Key)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != fileExtension {
+			continue
+		}
+
+		key, err := k.readKey(entry)
+		if err != nil {
+			return err
+		}
+		if key.Method().Alg() == k.algorithm {
+			keys[key.ID] = key
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+	k.reloadedAt = time.Now()
+	return nil
+}
+
+func (k *Keyring) readKey(entry os.DirEntry) (*Key, error) {
+	path := filepath.Join(k.dir, entry.Name())
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", path, err)
+	}
+	// The file's mtime changes when it is copied or restored; the kid does not
+	kid := strings.TrimSuffix(entry.Name(), fileExtension)
+	timestamp, _, _ := strings.Cut(kid, "-")
+	createdAt, err := time.Parse(kidTimeLayout, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: the file name must start with its creation time, e.g. %s-mykey%s", path, kidTimeLayout, fileExtension)
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("jwt key %s: no PEM data found", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", path, err)
+	}
+
+	var signer crypto.Signer
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		signer = private
+	case ed25519.PrivateKey:
+		signer = private
+	default:
+		return nil, fmt.Errorf("jwt key %s: unsupported key type %T, use RSA or Ed25519", path, parsed)
+	}
+
+	return &Key{
+		ID:        kid,
+		Private:   signer,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// SigningKey returns the key that signs new tokens: the newest key published
+// for at least ActivationDelay. Until one is, such as right after the first
+// key is created, the oldest key signs.
+func (k *Keyring) SigningKey() *Key {
+	return signingKey(k.Keys(), time.Now())
+}
+
+// LatestKey returns the newest key, which may not be signing yet.
+func (k *Keyring) LatestKey() *Key {
+	keys := k.Keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	return keys[len(keys)-1]
+}
+
+// VerificationKey returns the key with the kid. A kid it does not know makes
+// it read the directory once more, in case another instance just rotated.
+func (k *Keyring) VerificationKey(kid string) (*Key, error) {
+	if key := k.key(kid); key != nil {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	canReload := time.Since(k.reloadedAt) >= unknownKidReloadInterval
+	k.mu.RUnlock()
+
+	if canReload {
+		if err := k.Reload(); err != nil {
+			return nil, err
+		}
+		if key := k.key(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+func (k *Keyring) key(kid string) *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.keys[kid]
+}
+
+// Keys returns every key, oldest first, including those not signing yet.
+func (k *Keyring) Keys() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	sortByAge(keys)
+	return keys
+}
+
+// Rotate creates a new key and writes it to the directory. It is published at
+// once, and signs new tokens after ActivationDelay. The previous keys keep
+// verifying.
+func (k *Keyring) Rotate() (*Key, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	if k.algorithm == AlgorithmEdDSA {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generating jwt key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("encoding jwt key: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("generating jwt key id: %w", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	key := &Key{
+		ID:        now.Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix),
+		Private:   private,
+		CreatedAt: now,
+	}
+
+	path := filepath.Join(k.dir, key.ID+fileExtension)
+	contents := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		return nil, fmt.Errorf("writing jwt key: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key.ID] = key
+	return key, nil
+}
+
+// Prune deletes the keys that can no longer have signed a live token: those
+// replaced by a newer key more than maxTokenAge ago. It returns their kids.
+func (k *Keyring) Prune(maxTokenAge time.Duration) ([]string, error) {
+	keys := k.Keys()
+	cutoff := time.Now().Add(-maxTokenAge)
+
+	var pruned []string
+	var errs []error
+	for i := 0; i < len(keys)-1; i++ {
+		// A key stopped signing when the next one started
+		if keys[i+1].CreatedAt.Add(ActivationDelay).After(cutoff) {
+			break
+		}
+
+		err := os.Remove(filepath.Join(k.dir, keys[i].ID+fileExtension))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("removing jwt key %s: %w", keys[i].ID, err))
+			continue
+		}
+		pruned = append(pruned, keys[i].ID)
+	}
+
+	k.mu.Lock()
+	for _, kid := range pruned {
+		delete(k.keys, kid)
+	}
+	k.mu.Unlock()
+
+	return pruned, errors.Join(errs...)
+}
+
+// signingKey picks the signing key among keys sorted oldest first.
+func signingKey(keys []*Key, now time.Time) *Key {
+	if len(keys) == 0 {
+		return nil
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].CreatedAt.Add(ActivationDelay).After(now) {
+			return keys[i]
+		}
+	}
+	return keys[0]
+}
+
+// sortByAge sorts keys oldest first. Ties are broken by kid, so that instances
+// sharing the directory agree on the signing key.
+func sortByAge(keys []*Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
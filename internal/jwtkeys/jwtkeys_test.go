@@ -0,0 +1,219 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKey stores a new Ed25519 key created at createdAt and returns its kid.
+func writeKey(t *testing.T, dir string, createdAt time.Time, suffix string) string {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kid := createdAt.UTC().Format(kidTimeLayout) + "-" + suffix
+	contents := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+fileExtension), contents, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return kid
+}
+
+func TestOpenCreatesFirstKey(t *testing.T) {
+	dir := t.TempDir()
+
+	keys, err := Open(dir, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	// Nothing was published before, so the first key signs at once
+	key := keys.SigningKey()
+	if key == nil {
+		t.Fatal("SigningKey() = nil, want the first key")
+	}
+	if got := key.Method().Alg(); got != AlgorithmEdDSA {
+		t.Errorf("Method().Alg() = %q, want %q", got, AlgorithmEdDSA)
+	}
+	if len(keys.JWKS().Keys) != 1 {
+		t.Errorf("JWKS() has %d keys, want 1", len(keys.JWKS().Keys))
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name string
+		ages []time.Duration // of the keys, oldest first
+		want int             // index of the signing key
+	}{
+		{name: "single new key", ages: []time.Duration{time.Second}, want: 0},
+		{name: "single old key", ages: []time.Duration{48 * time.Hour}, want: 0},
+		{name: "new key still being published", ages: []time.Duration{48 * time.Hour, time.Minute}, want: 0},
+		{name: "new key published long enough", ages: []time.Duration{48 * time.Hour, ActivationDelay}, want: 1},
+		{name: "newest active key wins", ages: []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour, time.Minute}, want: 2},
+		{name: "no active key yet", ages: []time.Duration{2 * time.Minute, time.Minute}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make([]*Key, len(tt.ages))
+			for i, age := range tt.ages {
+				keys[i] = &Key{ID: string(rune('a' + i)), CreatedAt: now.Add(-age)}
+			}
+
+			if got := signingKey(keys, now); got != keys[tt.want] {
+				t.Errorf("signingKey() = %s, want %s", got.ID, keys[tt.want].ID)
+			}
+		})
+	}
+}
+
+func TestRotatePublishesBeforeSigning(t *testing.T) {
+	dir := t.TempDir()
+	oldKid := writeKey(t, dir, time.Now().Add(-48*time.Hour), "00000001")
+
+	keys, err := Open(dir, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	rotated, err := keys.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if got := keys.SigningKey().ID; got != oldKid {
+		t.Errorf("SigningKey() = %s right after Rotate, want the previous key %s", got, oldKid)
+	}
+	if got := keys.LatestKey().ID; got != rotated.ID {
+		t.Errorf("LatestKey() = %s, want %s", got, rotated.ID)
+	}
+	if _, err := keys.VerificationKey(rotated.ID); err != nil {
+		t.Errorf("VerificationKey(new kid): %v", err)
+	}
+
+	published := map[string]bool{}
+	for _, jwk := range keys.JWKS().Keys {
+		published[jwk.KeyID] = true
+	}
+	if !published[oldKid] || !published[rotated.ID] {
+		t.Errorf("JWKS() = %v, want both %s and %s", published, oldKid, rotated.ID)
+	}
+}
+
+func TestCreatedAtComesFromKid(t *testing.T) {
+	dir := t.TempDir()
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	kid := writeKey(t, dir, createdAt, "00000001")
+
+	// Touching the file must not make the key look new
+	if err := os.Chtimes(filepath.Join(dir, kid+fileExtension), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := Open(dir, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	key, err := keys.VerificationKey(kid)
+	if err != nil {
+		t.Fatalf("VerificationKey: %v", err)
+	}
+	if !key.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %s, want %s", key.CreatedAt, createdAt)
+	}
+}
+
+func TestOpenRejectsKeyWithoutTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	kid := writeKey(t, dir, time.Now(), "x")
+	if err := os.Rename(filepath.Join(dir, kid+fileExtension), filepath.Join(dir, "mykey"+fileExtension)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(dir, AlgorithmEdDSA); err == nil {
+		t.Error("Open() succeeded with a key file not named after its creation time")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	const maxTokenAge = time.Hour
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		ages       []time.Duration // of the keys, oldest first
+		wantPruned int             // number of oldest keys deleted
+	}{
+		{name: "single key is kept", ages: []time.Duration{72 * time.Hour}, wantPruned: 0},
+		{
+			name:       "replaced long ago",
+			ages:       []time.Duration{72 * time.Hour, 48 * time.Hour},
+			wantPruned: 1,
+		},
+		{
+			name: "successor not active for a token lifetime yet",
+			// The successor started signing ActivationDelay after its creation,
+			// so the old key signed tokens that are still live
+			ages:       []time.Duration{72 * time.Hour, maxTokenAge + ActivationDelay - time.Minute},
+			wantPruned: 0,
+		},
+		{
+			name:       "successor not signing yet",
+			ages:       []time.Duration{72 * time.Hour, time.Minute},
+			wantPruned: 0,
+		},
+		{
+			name:       "only keys no live token can use",
+			ages:       []time.Duration{96 * time.Hour, 72 * time.Hour, 48 * time.Hour, 30 * time.Minute},
+			wantPruned: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			kids := make([]string, len(tt.ages))
+			for i, age := range tt.ages {
+				kids[i] = writeKey(t, dir, now.Add(-age), string(rune('a'+i)))
+			}
+
+			keys, err := Open(dir, AlgorithmEdDSA)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			pruned, err := keys.Prune(maxTokenAge)
+			if err != nil {
+				t.Fatalf("Prune: %v", err)
+			}
+
+			if len(pruned) != tt.wantPruned {
+				t.Fatalf("Prune() deleted %v, want the %d oldest", pruned, tt.wantPruned)
+			}
+			for i, kid := range kids {
+				_, statErr := os.Stat(filepath.Join(dir, kid+fileExtension))
+				if deleted := os.IsNotExist(statErr); deleted != (i < tt.wantPruned) {
+					t.Errorf("key %s deleted = %t, want %t", kid, deleted, i < tt.wantPruned)
+				}
+			}
+			if keys.SigningKey() == nil {
+				t.Error("SigningKey() = nil after Prune")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats accepted by LOG_FORMAT.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New returns a logger that writes records in format ("json" or "text") at
+// level and above. Records logged with a request's context carry its request
+// ID, route pattern, user ID and trace ID.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(&contextHandler{handler})
+}
+
+// requestFields holds the values of a request that are only known once it has
+// passed some middlewares, such as the authenticated user.
+type requestFields struct {
+	userID atomic.Int64
+}
+
+type contextKey struct{}
+
+// withRequestFields returns a copy of ctx that SetUserID can record values in.
+func withRequestFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestFields{})
+}
+
+// SetUserID adds the authenticated user to every record logged with ctx, or a
+// context derived from it, including the access log line of the request.
+func SetUserID(ctx context.Context, userID int) {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		fields.userID.Store(int64(userID))
+	}
+}
+
+// contextHandler adds the request-scoped fields found in the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if routeCtx := chi.RouteContext(ctx); routeCtx != nil {
+		if pattern := routeCtx.RoutePattern(); pattern != "" {
+			record.AddAttrs(slog.String("route", pattern))
+		}
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanCtx.TraceID().String()))
+	}
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		if userID := fields.userID.Load(); userID > 0 {
+			record.AddAttrs(slog.Int64("user_id", userID))
+		}
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,93 @@
+// Package mailer sends the emails of the application, such as password reset
+// links. SMTPMailer delivers them; LogMailer and FileMailer keep them local for
+// development.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	config "dessert-ordering-go-system/internal/config"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the transport chosen by MAIL_TRANSPORT.
+func New(cfg config.MailConfig, logger *slog.Logger) Mailer {
+	switch cfg.Transport {
+	case config.TransportFile:
+		return &FileMailer{Path: cfg.File, From: cfg.From}
+	case config.TransportLog:
+		return &LogMailer{Logger: logger, From: cfg.From}
+	default:
+		return &SMTPMailer{
+			Addr:     net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(cfg.SMTP.Port)),
+			Host:     cfg.SMTP.Host,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		}
+	}
+}
+
+// LogMailer writes each message to the log instead of sending it. The body holds
+// live account links, so config only allows it with DEBUG=true.
+type LogMailer struct {
+	Logger *slog.Logger
+	From   string
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.Logger.InfoContext(ctx, "Email sent",
+		"from", m.From, "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileMailer appends each message to a file, in a format close to what an
+// SMTP server would receive.
+type FileMailer struct {
+	Path string
+	From string
+
+	mu sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Date: %s\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "From: %s\n", m.From)
+	fmt.Fprintf(&b, "To: %s\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\n\n", msg.Subject)
+	fmt.Fprintf(&b, "%s\n\n", strings.TrimRight(msg.Body, "\n"))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("writing mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scanners
+// probing random paths cannot create a series per path.
+const unmatchedRoute = "unmatched"
+
+// Middleware counts and times every request by its chi route pattern, which
+// is only known once the router has handled the request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+			if pattern := routeCtx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		statusLabel := strconv.Itoa(status)
+
+		m.RequestsTotal.WithLabelValues(route, r.Method, statusLabel).Inc()
+		m.RequestDuration.WithLabelValues(route, r.Method, statusLabel).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Handler serves the metrics in the Prometheus text format. When token is not
+// empty, scrapers must send it as "Authorization: Bearer <token>".
+func (m *Metrics) Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
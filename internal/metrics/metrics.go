@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// namespace prefixes the business metrics of the application.
+const namespace = "dessert"
+
+// Metrics holds the Prometheus collectors of the application. They are kept in
+// their own registry, so /metrics only exposes what is registered here.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	// HTTP requests by chi route pattern, method and status code
+	RequestsTotal   *prometheus.CounterVec
+	RequestDuration *prometheus.HistogramVec
+
+	// Business events
+	CartItemsAdded prometheus.Counter
+	Checkouts      prometheus.Counter
+	FailedLogins   prometheus.Counter
+	CSRFRejections prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by route pattern, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		CartItemsAdded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cart_items_added_total",
+			Help:      "Units of products added to a cart, by users and guests.",
+		}),
+		Checkouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checkouts_total",
+			Help:      "Orders placed through checkout.",
+		}),
+		FailedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Login attempts rejected because of a wrong contact or password.",
+		}),
+		CSRFRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "csrf_rejections_total",
+			Help:      "Requests rejected for a missing or invalid CSRF token.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.RequestsTotal,
+		m.RequestDuration,
+		m.CartItemsAdded,
+		m.Checkouts,
+		m.FailedLogins,
+		m.CSRFRejections,
+	)
+
+	return m
+}
+
+// RegisterDB exposes the connection pool statistics of db (sql.DB.Stats) as
+// the go_sql_* metrics.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedisPool exposes the number of active and idle Redis connections.
+func (m *Metrics) RegisterRedisPool(pool *redis.Pool) {
+	m.Registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_active_connections",
+			Help: "Redis connections in the pool, in use or idle.",
+		}, func() float64 {
+			return float64(pool.ActiveCount())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_idle_connections",
+			Help: "Idle Redis connections in the pool.",
+		}, func() float64 {
+			return float64(pool.IdleCount())
+		}),
+	)
+}
@@ -0,0 +1,465 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// lockName is the name of the MySQL advisory lock held while migrating.
+const lockName = "dessert_ordering_schema_migrations"
+
+// lockTimeout is how long to wait for another instance to finish migrating.
+const lockTimeout = 10 * time.Second
+
+var ErrLocked = errors.New("another instance is already running migrations")
+
+// fileNamePattern matches migration files such as 0001_create_users.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a single schema change with the SQL to apply and to revert it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied and when.
+type MigrationStatus struct {
+	*Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations to a database and records every
+// applied version in the schema_migrations table. Every dialect has its own
+// directory of migrations with the same versions.
+type Migrator struct {
+	DB         *sql.DB
+	Dialect    models.Dialect
+	Migrations []*Migration
+}
+
+func NewMigrator(db *sql.DB, dialect models.Dialect) (*Migrator, error) {
+	if !dialect.IsValid() {
+		return nil, fmt.Errorf("no migrations for database driver %q", dialect)
+	}
+
+	migrations, err := loadMigrations(files, string(dialect))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Dialect:    dialect,
+		Migrations: migrations,
+	}, nil
+}
+
+// loadMigrations reads the up and down files of dir and pairs them by version.
+func loadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		name, direction := matches[2], matches[3]
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// splitStatements splits a migration file into single statements, because the
+// MySQL driver only runs one statement per Exec unless multiStatements is set.
+// A semicolon only ends a statement outside of quotes, comments and the
+// BEGIN ... END body of a trigger. Comments are kept in the statement.
+func splitStatements(contents string) []string {
+	var (
+		statements []string
+		start      int
+		firstWord  string // of the current statement
+		inTrigger  bool   // the statement is a CREATE TRIGGER, whose body holds statements
+		depth      int    // open BEGIN and CASE blocks of the trigger body
+	)
+
+	appendStatement := func(end int) {
+		if statement := strings.TrimSpace(contents[start:end]); statement != "" && !isOnlyComments(statement) {
+			statements = append(statements, statement)
+		}
+		start = end + 1
+		firstWord, inTrigger, depth = "", false, 0
+	}
+
+	for i := 0; i < len(contents); i++ {
+		switch c := contents[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(contents, i)
+		case c == '-' && strings.HasPrefix(contents[i:], "--"), c == '#':
+			i = skipLine(contents, i)
+		case c == '/' && strings.HasPrefix(contents[i:], "/*"):
+			i = skipBlockComment(contents, i)
+		case c == ';':
+			if depth == 0 {
+				appendStatement(i)
+			}
+		case isWordStart(contents, i):
+			word := strings.ToUpper(readWord(contents, i))
+			i += len(word) - 1
+
+			switch {
+			case firstWord == "":
+				firstWord = word
+			case firstWord == "CREATE" && word == "TRIGGER" && depth == 0:
+				inTrigger = true
+			case !inTrigger:
+			case word == "BEGIN" || word == "CASE":
+				depth++
+			case word == "END":
+				// MySQL closes IF, LOOP, WHILE and REPEAT with END IF and so
+				// on; they open no block here, so neither does their END
+				next := strings.TrimLeft(contents[i+1:], " \t\r\n")
+				if len(next) > 0 && isWordByte(next[0]) {
+					switch strings.ToUpper(readWord(next, 0)) {
+					case "IF", "LOOP", "WHILE", "REPEAT":
+						continue
+					}
+				}
+				depth--
+			}
+		}
+	}
+	appendStatement(len(contents))
+
+	return statements
+}
+
+// skipQuoted returns the index of the quote closing the one at i. A doubled
+// quote or a backslash escapes it, as both MySQL and SQLite allow the former.
+func skipQuoted(s string, i int) int {
+	quote := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			if j+1 < len(s) && s[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+// skipLine returns the index of the end of the line comment starting at i.
+func skipLine(s string, i int) int {
+	if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(s) - 1
+}
+
+// skipBlockComment returns the index of the end of the /* */ comment at i.
+func skipBlockComment(s string, i int) int {
+	if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+		return i + 2 + end + 1
+	}
+	return len(s) - 1
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isWordStart reports whether a keyword or identifier starts at i.
+func isWordStart(s string, i int) bool {
+	return isWordByte(s[i]) && (i == 0 || !isWordByte(s[i-1]))
+}
+
+func readWord(s string, i int) string {
+	j := i
+	for j < len(s) && isWordByte(s[j]) {
+		j++
+	}
+	return s[i:j]
+}
+
+// isOnlyComments reports whether a statement holds nothing but comments, such
+// as a note after the last semicolon of a file.
+func isOnlyComments(statement string) bool {
+	for i := 0; i < len(statement); i++ {
+		switch c := statement[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '-' && strings.HasPrefix(statement[i:], "--"), c == '#':
+			i = skipLine(statement, i)
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			i = skipBlockComment(statement, i)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// withLock runs fn on a single connection that holds the migration lock.
+// MySQL named locks belong to a connection, so everything has to run on conn.
+// SQLite has no named locks; it backs local development and tests, where only
+// one process migrates a database file.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.Dialect == models.DialectMySQL {
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName, int(lockTimeout.Seconds())).Scan(&acquired)
+		if err != nil {
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return ErrLocked
+		}
+		defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, lockName)
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT UNSIGNED NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			applied_at DATETIME NOT NULL,
+			PRIMARY KEY (version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions returns the applied versions and when they were applied.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// run executes the statements of a migration file, then record, which updates
+// schema_migrations. On SQLite, where DDL is transactional, they share one
+// transaction: a failing statement leaves the database as it was. MySQL commits
+// every DDL statement on its own, so a migration that fails halfway stays half
+// applied and unrecorded, and has to be cleaned up by hand before running again.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, contents, record string, args ...any) error {
+	if m.Dialect != models.DialectSQLite {
+		for _, statement := range splitStatements(contents) {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+			return fmt.Errorf("failed to record it: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(contents) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record it: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration in order and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := m.run(ctx, conn, migration.Up,
+				`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`, migration.Version, migration.Name, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the
+// ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.Migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.Migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.run(ctx, conn, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("reverting migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists every known migration and whether it has been applied. It only
+// reads: it neither takes the lock nor creates schema_migrations, which counts
+// as no migration applied when it does not exist yet.
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	exists, err := m.hasMigrationsTable(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int]time.Time{}
+	if exists {
+		applied, err = appliedVersions(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := &MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// hasMigrationsTable reports whether schema_migrations has been created.
+func (m *Migrator) hasMigrationsTable(ctx context.Context, conn *sql.Conn) (bool, error) {
+	query := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'`
+	if m.Dialect == models.DialectSQLite {
+		query = `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	}
+
+	var count int
+	if err := conn.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to look for schema_migrations: %w", err)
+	}
+	return count > 0, nil
+}
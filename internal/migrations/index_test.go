@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []string
+	}{
+		{
+			name:     "plain statements",
+			contents: "CREATE TABLE a (id INT);\n\nCREATE TABLE b (id INT);\n",
+			want:     []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:     "semicolon in string literals",
+			contents: `INSERT INTO a VALUES ('x;y', "p;q", 'it''s;');`,
+			want:     []string{`INSERT INTO a VALUES ('x;y', "p;q", 'it''s;')`},
+		},
+		{
+			name:     "semicolon in quoted identifier",
+			contents: "CREATE TABLE `a;b` (id INT);",
+			want:     []string{"CREATE TABLE `a;b` (id INT)"},
+		},
+		{
+			name:     "semicolon in comments",
+			contents: "-- one; two\nCREATE TABLE a (id INT); /* three; four */\n# five; six\nCREATE TABLE b (id INT);",
+			want: []string{
+				"-- one; two\nCREATE TABLE a (id INT)",
+				"/* three; four */\n# five; six\nCREATE TABLE b (id INT)",
+			},
+		},
+		{
+			name:     "trailing comment only",
+			contents: "CREATE TABLE a (id INT);\n-- the end\n",
+			want:     []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "trigger body",
+			contents: `CREATE TRIGGER t AFTER INSERT ON a
+BEGIN
+	UPDATE b SET n = CASE WHEN n > 0 THEN n - 1 ELSE 0 END;
+	DELETE FROM c;
+END;
+CREATE TABLE d (id INT);`,
+			want: []string{
+				"CREATE TRIGGER t AFTER INSERT ON a\nBEGIN\n\tUPDATE b SET n = CASE WHEN n > 0 THEN n - 1 ELSE 0 END;\n\tDELETE FROM c;\nEND",
+				"CREATE TABLE d (id INT)",
+			},
+		},
+		{
+			name: "mysql trigger with END IF",
+			contents: `CREATE TRIGGER t BEFORE UPDATE ON a FOR EACH ROW
+BEGIN
+	IF NEW.n < 0 THEN
+		SET NEW.n = 0;
+	END IF;
+END;
+DROP TABLE e;`,
+			want: []string{
+				"CREATE TRIGGER t BEFORE UPDATE ON a FOR EACH ROW\nBEGIN\n\tIF NEW.n < 0 THEN\n\t\tSET NEW.n = 0;\n\tEND IF;\nEND",
+				"DROP TABLE e",
+			},
+		},
+		{
+			name:     "case outside a trigger",
+			contents: "UPDATE a SET n = CASE WHEN n > 0 THEN 1 ELSE 0 END; DELETE FROM b;",
+			want:     []string{"UPDATE a SET n = CASE WHEN n > 0 THEN 1 ELSE 0 END", "DELETE FROM b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.contents)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEmbeddedMigrationsSplit makes sure every shipped migration still splits
+// into statements, none of them empty.
+func TestEmbeddedMigrationsSplit(t *testing.T) {
+	for _, dir := range []string{"mysql", "sqlite"} {
+		migrations, err := loadMigrations(files, dir)
+		if err != nil {
+			t.Fatalf("loadMigrations(%q): %v", dir, err)
+		}
+		for _, migration := range migrations {
+			for _, contents := range []string{migration.Up, migration.Down} {
+				if len(splitStatements(contents)) == 0 {
+					t.Errorf("%s/%04d_%s has no statements", dir, migration.Version, migration.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN verified_at DATETIME NULL AFTER role;
+
+-- Accounts created before verification existed keep checking out
+UPDATE users SET verified_at = created_at;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id INT UNSIGNED NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY email_verifications_token_hash_unique (token_hash),
+    CONSTRAINT email_verifications_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
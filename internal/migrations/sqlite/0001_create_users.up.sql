@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(255) NOT NULL COLLATE NOCASE,
+    email VARCHAR(255) NOT NULL COLLATE NOCASE,
+    hash CHAR(60) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'customer',
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    CONSTRAINT users_username_unique UNIQUE (username),
+    CONSTRAINT users_email_unique UNIQUE (email)
+);
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    total_price DECIMAL(12, 2) NOT NULL,
+    total_quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    CONSTRAINT orders_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_index ON orders (user_id);
+
+CREATE INDEX IF NOT EXISTS orders_status_index ON orders (status);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    product_title VARCHAR(255) NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    quantity INTEGER NOT NULL,
+    total_price DECIMAL(12, 2) NOT NULL,
+    created_at DATETIME NOT NULL,
+    CONSTRAINT order_items_order_id_foreign FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE,
+    CONSTRAINT order_items_product_id_foreign FOREIGN KEY (product_id) REFERENCES products (id)
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_index ON order_items (order_id);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL,
+    from_status VARCHAR(20) NULL DEFAULT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    changed_by INTEGER NULL DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    CONSTRAINT order_status_history_order_id_foreign FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE,
+    CONSTRAINT order_status_history_changed_by_foreign FOREIGN KEY (changed_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_index ON order_status_history (order_id);
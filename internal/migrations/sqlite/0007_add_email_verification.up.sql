@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN verified_at DATETIME NULL;
+
+-- Accounts created before verification existed keep checking out
+UPDATE users SET verified_at = created_at;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    CONSTRAINT email_verifications_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT email_verifications_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS email_verifications_user_id_index ON email_verifications (user_id);
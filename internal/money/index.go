@@ -0,0 +1,237 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency used for amounts read from columns that do not
+// carry their own currency code.
+const DefaultCurrency = "USD"
+
+// Digits is the number of minor unit digits of every supported currency. Amounts
+// are stored in DECIMAL(x,2) columns, so currencies whose minor unit is not
+// cents (e.g. JPY or KWD) are rejected rather than silently rescaled.
+const Digits = 2
+
+// unsupportedCurrencies lists common currencies whose minor unit is not cents.
+var unsupportedCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+	"BHD": true,
+	"KWD": true,
+	"OMR": true,
+	"TND": true,
+}
+
+// Money is an exact amount of money stored as integer minor units (cents)
+// together with an ISO 4217 currency code.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns an amount of minor units in the given currency.
+func New(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns an empty amount in the given currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Supported reports whether amounts in the currency can be stored exactly.
+func Supported(currency string) bool {
+	return !unsupportedCurrencies[strings.ToUpper(currency)]
+}
+
+// Parse reads a decimal string such as "6.50" into an exact amount. It rejects
+// values with more than two fractional digits and unsupported currencies.
+func Parse(value, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !Supported(currency) {
+		return Money{}, fmt.Errorf("unsupported currency %s: only currencies with %d decimal places are supported", currency, Digits)
+	}
+	digits := Digits
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, fmt.Errorf("invalid amount: empty value")
+	}
+
+	negative := false
+	if value[0] == '-' || value[0] == '+' {
+		negative = value[0] == '-'
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, fmt.Errorf("invalid amount %q: no digits", value)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	// Trailing zeros beyond the minor unit are harmless, e.g. "6.5000" from a DECIMAL(10,4) column.
+	if len(fraction) > digits {
+		if strings.TrimRight(fraction[digits:], "0") != "" {
+			return Money{}, fmt.Errorf("invalid amount %q: %s allows at most %d decimal places", value, currency, digits)
+		}
+		fraction = fraction[:digits]
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return New(units, currency), nil
+}
+
+// MustParse is like Parse but panics on invalid input. It is meant for constants.
+func MustParse(value, currency string) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// currencyOf picks the currency of two operands. A zero value Money adopts the
+// currency of the other operand so that sums can start from Money{}.
+func currencyOf(a, b Money) string {
+	switch {
+	case a.Currency == "":
+		return b.Currency
+	case b.Currency == "" || a.Currency == b.Currency:
+		return a.Currency
+	default:
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", a.Currency, b.Currency))
+	}
+}
+
+// Add returns m + o. Adding amounts in different currencies panics.
+func (m Money) Add(o Money) Money {
+	return Money{Amount: m.Amount + o.Amount, Currency: currencyOf(m, o)}
+}
+
+// Sub returns m - o. Subtracting amounts in different currencies panics.
+func (m Money) Sub(o Money) Money {
+	return Money{Amount: m.Amount - o.Amount, Currency: currencyOf(m, o)}
+}
+
+// Multiply returns m * quantity.
+func (m Money) Multiply(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// String formats the amount as a plain decimal, e.g. "6.50".
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(math.Pow10(Digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, Digits, amount%scale)
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Display  string `json:"display"`
+}
+
+// MarshalJSON encodes the amount as minor units with its currency, plus a
+// preformatted decimal for display, e.g. {"amount":650,"currency":"USD","display":"6.50"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: currency, Display: m.String()})
+}
+
+// UnmarshalJSON accepts either the object produced by MarshalJSON or a decimal
+// string such as "6.50" in the default currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var decimal string
+	if err := json.Unmarshal(data, &decimal); err == nil {
+		parsed, err := Parse(decimal, m.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid amount: expected a decimal string or an object with amount and currency")
+	}
+	*m = New(raw.Amount, raw.Currency)
+	return nil
+}
+
+// Scan reads a DECIMAL column. The currency already set on m is kept, otherwise
+// DefaultCurrency is used. SQLite returns DECIMAL values as float64, which is
+// rounded to the nearest cent.
+func (m *Money) Scan(src any) error {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	switch v := src.(type) {
+	case []byte:
+		parsed, err := Parse(string(v), currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := Parse(v, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case int64:
+		// Whole units, e.g. an INTEGER column holding 6 for 6.00
+		*m = New(v*int64(math.Pow10(Digits)), currency)
+	case float64:
+		*m = New(int64(math.Round(v*math.Pow10(Digits))), currency)
+	case nil:
+		*m = Zero(currency)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+// Value stores the amount as an exact decimal string for DECIMAL columns.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,109 @@
+package money
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "6.50", currency: "USD", want: Money{Amount: 650, Currency: "USD"}},
+		{value: "6.5", currency: "usd", want: Money{Amount: 650, Currency: "USD"}},
+		{value: "6", currency: "", want: Money{Amount: 600, Currency: DefaultCurrency}},
+		{value: ".05", currency: "EUR", want: Money{Amount: 5, Currency: "EUR"}},
+		{value: " -1.25 ", currency: "USD", want: Money{Amount: -125, Currency: "USD"}},
+		{value: "+3.00", currency: "USD", want: Money{Amount: 300, Currency: "USD"}},
+		{value: "6.5000", currency: "USD", want: Money{Amount: 650, Currency: "USD"}},
+		{value: "0.10", currency: "USD", want: Money{Amount: 10, Currency: "USD"}},
+		{value: "1.005", currency: "USD", wantErr: true},
+		{value: "", currency: "USD", wantErr: true},
+		{value: "abc", currency: "USD", wantErr: true},
+		{value: "1.-5", currency: "USD", wantErr: true},
+		{value: "--1", currency: "USD", wantErr: true},
+		{value: "-", currency: "USD", wantErr: true},
+		{value: "+", currency: "USD", wantErr: true},
+		{value: ".", currency: "USD", wantErr: true},
+		{value: "-.", currency: "USD", wantErr: true},
+		{value: "99999999999999999999", currency: "USD", wantErr: true},
+		{value: "650", currency: "JPY", wantErr: true},
+		{value: "1.500", currency: "KWD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.currency, func(t *testing.T) {
+			got, err := Parse(tt.value, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q, %q) = %v, want an error", tt.value, tt.currency, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q, %q): %v", tt.value, tt.currency, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   string
+	}{
+		{amount: 650, want: "6.50"},
+		{amount: 5, want: "0.05"},
+		{amount: 0, want: "0.00"},
+		{amount: -125, want: "-1.25"},
+		{amount: -5, want: "-0.05"},
+		{amount: 123456750, want: "1234567.50"},
+	}
+
+	for _, tt := range tests {
+		if got := New(tt.amount, "USD").String(); got != tt.want {
+			t.Errorf("New(%d).String() = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      any
+		currency string // already set on the destination
+		want     Money
+		wantErr  bool
+	}{
+		{name: "mysql decimal", src: []byte("1234567.50"), want: Money{Amount: 123456750, Currency: DefaultCurrency}},
+		{name: "string", src: "6.50", currency: "EUR", want: Money{Amount: 650, Currency: "EUR"}},
+		{name: "sqlite float", src: 1234567.5, want: Money{Amount: 123456750, Currency: DefaultCurrency}},
+		{name: "float rounding", src: 0.1 + 0.2, want: Money{Amount: 30, Currency: DefaultCurrency}},
+		{name: "integer", src: int64(6), want: Money{Amount: 600, Currency: DefaultCurrency}},
+		{name: "null", src: nil, currency: "EUR", want: Money{Amount: 0, Currency: "EUR"}},
+		{name: "too many decimals", src: []byte("1.005"), wantErr: true},
+		{name: "unsupported type", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Money{Currency: tt.currency}
+			err := m.Scan(tt.src)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Scan(%v) = %+v, want an error", tt.src, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v): %v", tt.src, err)
+			}
+			if m != tt.want {
+				t.Errorf("Scan(%v) = %+v, want %+v", tt.src, m, tt.want)
+			}
+		})
+	}
+}
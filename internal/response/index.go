@@ -2,7 +2,7 @@ package response
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -67,7 +67,7 @@ func WriteJsonResponse(w http.ResponseWriter, statusCode int, data interface{})
 	w.WriteHeader(statusCode)
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
-		log.Printf("error: failed to encode JSON response: %v", err)
+		slog.Error("failed to encode JSON response", "error", err)
 	}
 }
 
@@ -79,6 +79,6 @@ func WriteJsonHeadersResponse(w http.ResponseWriter, statusCode int, data interf
 	w.WriteHeader(statusCode)
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
-		log.Printf("error: failed to encode JSON response: %v", err)
+		slog.Error("failed to encode JSON response", "error", err)
 	}
 }
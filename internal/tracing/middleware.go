@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a span for every request, continuing the trace of the
+// caller when it sends a traceparent header. The span is named after the chi
+// route pattern and carries the request ID. It must run after middleware.RequestID.
+//
+// Probes, /metrics and static files are not traced.
+func Middleware(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if requestID := middleware.GetReqID(r.Context()); requestID != "" {
+			span.SetAttributes(attribute.String("request_id", requestID))
+		}
+
+		next.ServeHTTP(w, r)
+
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+			if pattern := routeCtx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+		}
+	})
+
+	return otelhttp.NewHandler(named, "http.request", otelhttp.WithFilter(traced))
+}
+
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return !strings.HasPrefix(r.URL.Path, "/static/")
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("dessert-ordering-go-system/internal/tracing")
+
+// SessionStore records the session reads and writes of store, a Redis store
+// at addr, as spans of the request that made them.
+func SessionStore(store scs.Store, addr string) scs.CtxStore {
+	return &sessionStore{Store: store, addr: addr}
+}
+
+type sessionStore struct {
+	scs.Store
+	addr string
+}
+
+func (s *sessionStore) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "redis "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName(operation),
+			semconv.ServerAddress(s.addr),
+		),
+	)
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *sessionStore) FindCtx(ctx context.Context, token string) (b []byte, found bool, err error) {
+	_, span := s.start(ctx, "GET")
+	defer func() { end(span, err) }()
+	return s.Store.Find(token)
+}
+
+func (s *sessionStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) (err error) {
+	_, span := s.start(ctx, "SET")
+	defer func() { end(span, err) }()
+	return s.Store.Commit(token, b, expiry)
+}
+
+func (s *sessionStore) DeleteCtx(ctx context.Context, token string) (err error) {
+	_, span := s.start(ctx, "DEL")
+	defer func() { end(span, err) }()
+	return s.Store.Delete(token)
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+
+	money "dessert-ordering-go-system/internal/money"
 )
 
 // GenerateRandomString returns a URL-safe, base64 encoded
@@ -17,6 +19,8 @@ func GenerateRandomString(byteLength int) (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-func FormatPrice(price float64) string {
-	return fmt.Sprintf("%.2f", price)
+// FormatPrice formats an amount as a plain decimal with the currency's minor
+// unit digits, e.g. "6.50".
+func FormatPrice(price money.Money) string {
+	return price.String()
 }
@@ -1,30 +1,136 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	app "dessert-ordering-go-system/internal/app"
+	commands "dessert-ordering-go-system/internal/commands"
+	config "dessert-ordering-go-system/internal/config"
 	routes "dessert-ordering-go-system/routes"
 )
 
 func main() {
-	a := app.NewApplication()
-	appRoutes := routes.NewRoutes(a)
+	// Subcommands such as "migrate up" run and exit without starting the server
+	handled, err := commands.Run(os.Args[1:])
+	if handled {
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	a := app.NewApplication(cfg)
+
+	os.Exit(serve(a))
+}
 
-	defer a.DB.Close() // Ensure the connection is closed when main exits
-	defer a.RedisPool.Close()
+// serve runs the HTTP server until it receives SIGINT or SIGTERM, then drains
+// in-flight requests for up to the shutdown timeout and releases the
+// application's resources. It returns the process exit code.
+func serve(a *app.Application) int {
+	appRoutes := routes.NewRoutes(a)
+	shutdownTimeout := a.Config.Server.ShutdownTimeout
 
 	// Configure server with timeouts
 	s := &http.Server{
-		Addr:         ":8080",
+		Addr:         a.Config.Server.Addr,
 		Handler:      appRoutes,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  a.Config.Server.ReadTimeout,
+		WriteTimeout: a.Config.Server.WriteTimeout,
+		IdleTimeout:  a.Config.Server.IdleTimeout,
+	}
+
+	// Metrics get a listener of their own when METRICS_ADDR is set, which keeps
+	// them off the public port
+	var metricsServer *http.Server
+	if a.Config.Metrics.Addr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", a.Metrics.Handler(a.Config.Metrics.Token))
+
+		metricsServer = &http.Server{
+			Addr:         a.Config.Metrics.Addr,
+			Handler:      metricsMux,
+			ReadTimeout:  a.Config.Server.ReadTimeout,
+			WriteTimeout: a.Config.Server.WriteTimeout,
+		}
+	}
+
+	// SIGINT (Ctrl+C) and SIGTERM (sent on deploys) start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		a.Logger.Info("Server started", "addr", s.Addr)
+		serverErr <- s.ListenAndServe()
+	}()
+	if metricsServer != nil {
+		go func() {
+			a.Logger.Info("Metrics server started", "addr", metricsServer.Addr)
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	exitCode := 0
+
+	select {
+	case err := <-serverErr:
+		a.Logger.Error("Server stopped unexpectedly", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		a.Logger.Info("Shutdown signal received")
+	}
+
+	// A second signal kills the process straight away
+	stop()
+
+	// Fail readiness checks first, and give load balancers time to notice
+	a.BeginShutdown()
+	if delay := a.Config.Server.ShutdownDelay; delay > 0 && exitCode == 0 {
+		a.Logger.Info("Still serving while load balancers take the instance out", "delay", delay.String())
+		time.Sleep(delay)
+	}
+	a.Logger.Info("Waiting for in-flight requests", "timeout", shutdownTimeout.String())
+
+	// Each stage gets the whole SHUTDOWN_TIMEOUT, so slow requests cannot use up
+	// the time the workers need to stop and the last spans need to be flushed
+	// 1. Stop accepting connections and let in-flight requests finish
+	httpCtx, cancelHTTP := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelHTTP()
+	err := s.Shutdown(httpCtx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		a.Logger.Error("Requests still running after the shutdown timeout, closing their connections", "error", err)
+		s.Close()
+		exitCode = 1
+	} else {
+		a.Logger.Info("HTTP server stopped")
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(httpCtx); err != nil {
+			metricsServer.Close()
+		}
 	}
 
-	a.Loggers.Info.Println("Server Started On PORT :8080")
+	// 2. Stop background workers and close the database and Redis
+	appCtx, cancelApp := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelApp()
+	err = a.Shutdown(appCtx)
+	if err != nil {
+		exitCode = 1
+	}
 
-	a.Loggers.Error.Fatal(s.ListenAndServe())
+	a.Logger.Info("Shutdown complete")
+	return exitCode
 }
@@ -10,8 +10,10 @@ import (
 
 	app "dessert-ordering-go-system/internal/app"
 	appConstants "dessert-ordering-go-system/internal/app_constants"
+	logging "dessert-ordering-go-system/internal/logging"
 	responses "dessert-ordering-go-system/internal/response"
 	utils "dessert-ordering-go-system/internal/utils"
+	models "dessert-ordering-go-system/models"
 	services "dessert-ordering-go-system/services"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -38,7 +40,7 @@ func (m *Middlewares) EnableCSRF(next http.Handler) http.Handler {
 				// 2. It's not available. Add in a new field to initialize it and generate csrf_token
 				csrfToken, err := utils.GenerateRandomString(32)
 				if err != nil {
-					m.Loggers.Error.Printf("failed to generate CSRF Token :%v", err)
+					m.Logger.ErrorContext(r.Context(), "failed to generate CSRF Token", "error", err)
 				} else {
 					m.Session.Put(r.Context(), appConstants.X_CSRF_Token, csrfToken)
 				}
@@ -51,7 +53,7 @@ func (m *Middlewares) EnableCSRF(next http.Handler) http.Handler {
 				if csrfToken == "" {
 					newCsrfToken, err := utils.GenerateRandomString(32)
 					if err != nil {
-						m.Loggers.Error.Printf("failed to generate CSRF Token :%v", err)
+						m.Logger.ErrorContext(r.Context(), "failed to generate CSRF Token", "error", err)
 					} else {
 						m.Session.Put(r.Context(), appConstants.X_CSRF_Token, newCsrfToken)
 					}
@@ -80,8 +82,10 @@ func (m *Middlewares) RequireCSRF(next http.Handler) http.Handler {
 
 			// 3. Compare the two tokens and check if expected token exists
 			if expectedToken == "" || tokenFromRequest == "" || tokenFromRequest != expectedToken {
-				m.Loggers.Error.Printf("CSRF token validation failed for request %s %s from %s (Expected: %q, Received: %q)",
-					r.Method, r.URL.Path, r.RemoteAddr, expectedToken, tokenFromRequest)
+				m.Logger.WarnContext(r.Context(), "CSRF token validation failed",
+					"method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr,
+					"expected", expectedToken, "received", tokenFromRequest)
+				m.Metrics.CSRFRejections.Inc()
 
 				// 4. Handle forbidden response based on Accept header
 				acceptType := r.Header.Get("Accept")
@@ -113,32 +117,10 @@ func (m *Middlewares) AuthRequired(next http.Handler) http.Handler {
 		acceptType := r.Header.Get("Accept")
 
 		// JWT Authentication
-		var tokenString string = ""
-
-		// 1. Try to get the token from the HttpOnly cookie first
-		cookie, err := r.Cookie(appConstants.Jwt_Name)
-		if err == nil {
-			tokenString = cookie.Value
-		} else if errors.Is(err, http.ErrNoCookie) {
-			// 2. If not in the cookie, try the Authorization Header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader != "" {
-				headerParts := strings.Split(authHeader, " ")
-				if len(headerParts) == 2 && headerParts[0] == "Bearer" {
-					tokenString = headerParts[1]
-				}
-			}
-		}
+		tokenString, _ := authToken(r)
 
 		if tokenString != "" {
-			claims := &services.UserClaims{}
-
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return m.Services.Auth.JWTSecret, nil
-			})
+			claims, err := m.Services.Auth.ParseAuthToken(tokenString)
 			if err != nil {
 				if errors.Is(err, jwt.ErrSignatureInvalid) {
 					if strings.HasPrefix(acceptType, "application/json") {
@@ -171,34 +153,25 @@ func (m *Middlewares) AuthRequired(next http.Handler) http.Handler {
 				return
 			}
 
-			if !token.Valid {
-				if strings.HasPrefix(acceptType, "application/json") {
-					response := responses.NewErrorJsonResponse("Invalid token (general validation failure)")
-					responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
-					return
-				}
-				m.Session.SetFlashError(r.Context(), "Invalid token (general validation failure)")
-				http.Redirect(w, r, "/login", http.StatusSeeOther)
+			// Refuse tokens revoked by a logout, here or on another device
+			revoked, err := m.Services.Auth.IsAuthTokenRevoked(r.Context(), claims)
+			if err != nil {
+				m.Logger.ErrorContext(r.Context(), "AuthRequired - m.Services.Auth.IsAuthTokenRevoked", "error", err)
+				m.writeRevocationCheckError(w, r)
 				return
 			}
-
-			// Check the CSRF Token
-			csrfTokenFromHeader := r.Header.Get(appConstants.X_CSRF_Token)
-			expectedCsrfToken := m.Session.GetCsrfToken(r.Context())
-
-			if expectedCsrfToken != csrfTokenFromHeader {
+			if revoked {
 				if strings.HasPrefix(acceptType, "application/json") {
-					response := responses.NewErrorJsonResponse("Invalid CSRF Token")
+					response := responses.NewErrorJsonResponse("Token has been revoked")
 					responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
 					return
 				}
-				m.Session.SetFlashError(r.Context(), "Invalid CSRF Token")
+				m.Session.SetFlashError(r.Context(), "Token has been revoked")
 				http.Redirect(w, r, "/login", http.StatusSeeOther)
 				return
 			}
 
-			m.Session.SetAuthUserID(r.Context(), claims.ID)
-			next.ServeHTTP(w, r)
+			m.serveWithClaims(w, r, next, claims)
 			return
 		}
 
@@ -213,6 +186,164 @@ func (m *Middlewares) AuthRequired(next http.Handler) http.Handler {
 
 			m.Session.SetFlashError(r.Context(), "authentication credentials were not found")
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		// Refuse sessions that a logout everywhere has ended
+		userID := m.Session.GetAuthUserID(r.Context())
+		revoked, err := m.endRevokedSession(r)
+		if err != nil {
+			m.Logger.ErrorContext(r.Context(), "AuthRequired - m.endRevokedSession", "error", err)
+			m.writeRevocationCheckError(w, r)
+			return
+		}
+		if revoked {
+			if strings.HasPrefix(acceptType, "application/json") {
+				response := responses.NewErrorJsonResponse("Session has been revoked, please log in again")
+				responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
+				return
+			}
+
+			m.Session.SetFlashError(r.Context(), "Session has been revoked, please log in again")
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		logging.SetUserID(r.Context(), userID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRevocationCheckError answers a request whose credentials could not be
+// checked against the denylist. It fails closed: a revoked token must not get
+// through while Redis is unreachable.
+func (m *Middlewares) writeRevocationCheckError(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		response := responses.NewErrorJsonResponse("Could not verify credentials, please try again")
+		responses.WriteJsonResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+	http.Error(w, "Could not verify credentials, please try again", http.StatusServiceUnavailable)
+}
+
+// authToken returns the JWT of the request, from the HttpOnly cookie or else the
+// Authorization header, and whether it came from the cookie.
+func authToken(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(appConstants.Jwt_Name)
+	if err == nil {
+		return cookie.Value, true
+	}
+
+	headerParts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(headerParts) == 2 && headerParts[0] == "Bearer" {
+		return headerParts[1], false
+	}
+	return "", false
+}
+
+// withoutAuthToken returns a copy of r without its JWT cookie and Authorization
+// header, so that the handlers after it see an anonymous request.
+func withoutAuthToken(r *http.Request) *http.Request {
+	r = r.Clone(r.Context())
+	r.Header.Del("Authorization")
+
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != appConstants.Jwt_Name {
+			r.AddCookie(cookie)
+		}
+	}
+	return r
+}
+
+// serveWithClaims checks the CSRF token of a request authenticated by a valid
+// JWT and serves it as the token's user.
+func (m *Middlewares) serveWithClaims(w http.ResponseWriter, r *http.Request, next http.Handler, claims *services.UserClaims) {
+	csrfTokenFromHeader := r.Header.Get(appConstants.X_CSRF_Token)
+	expectedCsrfToken := m.Session.GetCsrfToken(r.Context())
+
+	if expectedCsrfToken != csrfTokenFromHeader {
+		if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+			response := responses.NewErrorJsonResponse("Invalid CSRF Token")
+			responses.WriteJsonResponse(w, http.StatusUnauthorized, response)
+			return
+		}
+		m.Session.SetFlashError(r.Context(), "Invalid CSRF Token")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	m.Session.SetAuthUserID(r.Context(), claims.ID)
+	m.Session.SetAuthUserRole(r.Context(), claims.Role)
+	if claims.IssuedAt != nil {
+		m.Session.SetAuthLoggedInAt(r.Context(), claims.IssuedAt.Time)
+	}
+	logging.SetUserID(r.Context(), claims.ID)
+	next.ServeHTTP(w, r.WithContext(services.WithUserClaims(r.Context(), claims)))
+}
+
+// endRevokedSession logs the session out when a logout everywhere has ended it,
+// and reports whether it did.
+func (m *Middlewares) endRevokedSession(r *http.Request) (bool, error) {
+	userID := m.Session.GetAuthUserID(r.Context())
+	revoked, err := m.Services.Auth.IsSessionRevoked(r.Context(), userID, m.Session.GetAuthLoggedInAt(r.Context()))
+	if err != nil || !revoked {
+		return false, err
+	}
+
+	if err := m.Session.RenewToken(r.Context()); err != nil {
+		m.Logger.ErrorContext(r.Context(), "endRevokedSession - m.Session.RenewToken", "error", err)
+	}
+	m.Session.RemoveAuthUserID(r.Context())
+	return true, nil
+}
+
+// AuthOptional lets anonymous visitors through. A valid JWT or session is
+// checked like AuthRequired checks it, but a stale, invalid or revoked one does
+// not send the visitor to the login page: its cookie is cleared and the request
+// is served as an anonymous one.
+func (m *Middlewares) AuthOptional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokenString, fromCookie := authToken(r); tokenString != "" {
+			claims, err := m.Services.Auth.ParseAuthToken(tokenString)
+			if err == nil {
+				revoked, err := m.Services.Auth.IsAuthTokenRevoked(r.Context(), claims)
+				if err != nil {
+					m.Logger.ErrorContext(r.Context(), "AuthOptional - m.Services.Auth.IsAuthTokenRevoked", "error", err)
+					m.writeRevocationCheckError(w, r)
+					return
+				}
+				if !revoked {
+					m.serveWithClaims(w, r, next, claims)
+					return
+				}
+			}
+
+			if fromCookie {
+				http.SetCookie(w, &http.Cookie{
+					Name:     appConstants.Jwt_Name,
+					Expires:  time.Unix(0, 0),
+					MaxAge:   -1,
+					HttpOnly: true,
+					Secure:   m.Config.Session.SecureCookies,
+					SameSite: http.SameSiteLaxMode,
+					Path:     "/",
+				})
+			}
+			r = withoutAuthToken(r)
+		}
+
+		if m.Session.Exists(r.Context(), appConstants.Auth_User_ID) {
+			revoked, err := m.endRevokedSession(r)
+			if err != nil {
+				m.Logger.ErrorContext(r.Context(), "AuthOptional - m.endRevokedSession", "error", err)
+				m.writeRevocationCheckError(w, r)
+				return
+			}
+			if !revoked {
+				logging.SetUserID(r.Context(), m.Session.GetAuthUserID(r.Context()))
+			}
 		}
 
 		next.ServeHTTP(w, r)
@@ -237,3 +368,31 @@ func (m *Middlewares) AuthNotRequired(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireRole only lets users with one of the given roles through. It must run
+// after AuthRequired, which stores the role in the session.
+func (m *Middlewares) RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := m.Session.GetAuthUserRole(r.Context())
+
+			if !slices.Contains(roles, role) {
+				m.Logger.WarnContext(r.Context(), "Role check failed",
+					"method", r.Method, "path", r.URL.Path, "role", role, "required", roles)
+
+				acceptType := r.Header.Get("Accept")
+				if strings.HasPrefix(acceptType, "application/json") {
+					response := responses.NewErrorJsonResponse("you do not have permission to access this resource")
+					responses.WriteJsonResponse(w, http.StatusForbidden, response)
+					return
+				}
+
+				m.Session.SetFlashError(r.Context(), "you do not have permission to access this resource")
+				http.Redirect(w, r, "/", http.StatusSeeOther)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
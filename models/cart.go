@@ -1,9 +1,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"time"
+
+	money "dessert-ordering-go-system/internal/money"
 )
 
 type Cart = []*CartItem
@@ -32,23 +35,28 @@ type CartItemSimplified struct {
 type CartDisplayItem struct {
 	Product    *CartItemProduct
 	CartItem   *CartItemSimplified
-	TotalPrice float64
+	TotalPrice money.Money
 }
 
 type CartItemProduct struct {
-	ID          int     `json:"id"`
-	Title       string  `json:"title"`
-	Category    string  `json:"category"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Thumbnail   string  `json:"thumbnail"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Category    string      `json:"category"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Thumbnail   string      `json:"thumbnail"`
+	Stock       int         `json:"stock"`
 }
 
 type CartItemModel struct {
-	DB *sql.DB
+	DB           *sql.DB
+	QueryTimeout time.Duration
 }
 
-func (m *CartItemModel) GetCartItems(userID int) ([]*CartItem, error) {
+func (m *CartItemModel) GetCartItems(ctx context.Context, userID int) ([]*CartItem, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1. Construct the SQL query.
 	query := `
 					SELECT
@@ -64,7 +72,8 @@ func (m *CartItemModel) GetCartItems(userID int) ([]*CartItem, error) {
 						p.category,
 						p.description,
 						p.price,
-						p.thumbnail
+						p.thumbnail,
+						p.stock
 					FROM
 						cart_items as ci
 					LEFT JOIN
@@ -77,10 +86,10 @@ func (m *CartItemModel) GetCartItems(userID int) ([]*CartItem, error) {
 	`
 
 	// 2. Execute the query
-	rows, err := m.DB.Query(query, userID)
+	rows, err := m.DB.QueryContext(ctx, query, userID)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.GetCartItems - m.DB.Query: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "CartItemModel.GetCartItems - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -107,35 +116,40 @@ func (m *CartItemModel) GetCartItems(userID int) ([]*CartItem, error) {
 			&cartItem.Product.Description,
 			&cartItem.Product.Price,
 			&cartItem.Product.Thumbnail,
+			&cartItem.Product.Stock,
 		)
 		if err != nil {
-			log.Printf("ERROR: CartItemModel.GetCartItems - rows.Scan: %v", err)
-			return nil, err
+			slog.ErrorContext(ctx, "CartItemModel.GetCartItems - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
 		}
 		cartItems = append(cartItems, &cartItem)
 	}
 
 	// 4. Check for errors from iterating over the rows.
 	if err := rows.Err(); err != nil {
-		log.Printf("ERROR: CartItem.GetCartItems - rows.Err: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "CartItem.GetCartItems - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
 	}
 
 	return cartItems, nil
 }
 
-func (m *CartItemModel) AddCartItem(userID, productID, quantity int) error {
+func (m *CartItemModel) AddCartItem(ctx context.Context, userID, productID, quantity int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// -1. Start a database transaction
 	// 0. Check if the product is on the database
 	// 1. Check if the product is already in the user cart
-	// 2. If it's not perform an insert
-	// 3. If it's there perform an update (and skip No. 3)
+	// 2. Check that the new quantity does not exceed the stock
+	// 3. If it's not in the cart perform an insert
+	// 4. If it's there perform an update (and skip No. 3)
 
 	// -1
-	tx, err := m.DB.Begin()
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.AddCartItem - m.DB.Begin: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.AddCartItem - m.DB.Begin", "error", err)
+		return queryError(ctx, err)
 	}
 
 	defer tx.Rollback()
@@ -143,24 +157,30 @@ func (m *CartItemModel) AddCartItem(userID, productID, quantity int) error {
 	// 0
 	productQuery := `
 				SELECT
-					p.id
+					p.id,
+					p.title,
+					p.stock
 				FROM 
 					products AS p
 				WHERE
-					p.id = ?
+					p.id = ? AND p.archived_at IS NULL
 	`
 
-	var pID int
+	var (
+		pID    int
+		pTitle string
+		pStock int
+	)
 
-	productRow := tx.QueryRow(productQuery, productID)
-	err = productRow.Scan(&pID)
+	productRow := tx.QueryRowContext(ctx, productQuery, productID)
+	err = productRow.Scan(&pID, &pTitle, &pStock)
 
 	if err == sql.ErrNoRows {
-		log.Printf("ERROR: CartItemModel.AddCartItem - Product with ID %d not found.", productID)
+		slog.WarnContext(ctx, "CartItemModel.AddCartItem - product not found", "product_id", productID)
 		return ErrProductNotFound
 	} else if err != nil {
-		log.Printf("ERROR: CartItemModel.AddCartItem - productRow.Scan: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.AddCartItem - productRow.Scan", "error", err)
+		return queryError(ctx, err)
 	}
 
 	// 1.
@@ -179,7 +199,7 @@ func (m *CartItemModel) AddCartItem(userID, productID, quantity int) error {
 				LIMIT 1
 	`
 
-	cartRow := tx.QueryRow(cartItemQuery, productID, userID)
+	cartRow := tx.QueryRowContext(ctx, cartItemQuery, productID, userID)
 	err = cartRow.Scan(
 		&cartItem.ID,
 		&cartItem.UserID,
@@ -187,90 +207,101 @@ func (m *CartItemModel) AddCartItem(userID, productID, quantity int) error {
 		&cartItem.Quantity,
 	)
 	if err != nil && err != sql.ErrNoRows {
-		log.Printf("ERROR: CartItemModel.AddCartItem - cartRow.Scan: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.AddCartItem - cartRow.Scan", "error", err)
+		return queryError(ctx, err)
 	}
 
 	// 2.
+	if cartItem.Quantity+quantity > pStock {
+		return &InsufficientStockError{ProductID: productID, Title: pTitle, Requested: cartItem.Quantity + quantity, Available: pStock}
+	}
+
+	// 3.
 	if err == sql.ErrNoRows {
 		insertQuery := `
 		INSERT INTO cart_items(
 			product_id, user_id, quantity, created_at, updated_at
 		) VALUES (?, ?, ?, ?, ?)
 	`
-		_, err := tx.Exec(insertQuery, productID, userID, quantity, time.Now(), time.Now())
+		_, err := tx.ExecContext(ctx, insertQuery, productID, userID, quantity, time.Now(), time.Now())
 		if err != nil {
-			log.Printf("ERROR: CartItemModel.AddCartItem - Create CartItem - m.DB.Exec: %v", err)
-			return err
+			slog.ErrorContext(ctx, "CartItemModel.AddCartItem - Create CartItem - m.DB.Exec", "error", err)
+			return queryError(ctx, err)
 		}
 
 		err = tx.Commit()
 		if err != nil {
-			log.Printf("ERROR: CartItemModel.AddCartItem - tx.Commit failed: %v", err)
-			return err
+			slog.ErrorContext(ctx, "CartItemModel.AddCartItem - tx.Commit failed", "error", err)
+			return queryError(ctx, err)
 		}
 
 		return nil
 	} else if err != nil {
-		log.Printf("ERROR: CartItem.AddCartItem - cartRow.Scan: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItem.AddCartItem - cartRow.Scan", "error", err)
+		return queryError(ctx, err)
 	}
 
-	// 3
+	// 4
 	updateQuery := `
 			UPDATE cart_items
 			SET
-				cart_items.quantity = ?,
-				cart_items.updated_at = ?
+				quantity = ?,
+				updated_at = ?
 			WHERE 
 				cart_items.id = ?
 		`
-	_, err = tx.Exec(updateQuery, cartItem.Quantity+quantity, time.Now(), cartItem.ID)
+	_, err = tx.ExecContext(ctx, updateQuery, cartItem.Quantity+quantity, time.Now(), cartItem.ID)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.AddCartItem - Update CartItem - m.DB.Exec: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.AddCartItem - Update CartItem - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.AddCartItem - tx.Commit failed: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.AddCartItem - tx.Commit failed", "error", err)
+		return queryError(ctx, err)
 	}
 
 	return nil
 }
 
-func (m *CartItemModel) RemoveCartItem(userID int, cartItemID int) error {
+func (m *CartItemModel) RemoveCartItem(ctx context.Context, userID int, cartItemID int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	deleteQuery := `
 		DELETE FROM cart_items
 		WHERE id = ? AND user_id = ?
 	`
 
-	result, err := m.DB.Exec(deleteQuery, cartItemID, userID)
+	result, err := m.DB.ExecContext(ctx, deleteQuery, cartItemID, userID)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.RemoveCartItem - m.DB.Exec: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.RemoveCartItem - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.RemoveCartItem - result.RowsAffected failed for cartItemID %d, userID %d: %v", cartItemID, userID, err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.RemoveCartItem - result.RowsAffected", "cart_item_id", cartItemID, "error", err)
+		return queryError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
-		log.Printf("cart item with the specified ID - %d not found.", cartItemID)
+		slog.InfoContext(ctx, "CartItemModel.RemoveCartItem - cart item not found", "cart_item_id", cartItemID)
 		return ErrCartItemNotFound
 	}
 	return nil
 }
 
-func (m *CartItemModel) RemoveSingleCartItem(userID, productID int) error {
+func (m *CartItemModel) RemoveSingleCartItem(ctx context.Context, userID, productID int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// Step 0: Start a database transaction for atomicity
-	tx, err := m.DB.Begin()
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.RemoveSingleCartItem - m.DB.Begin failed: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.RemoveSingleCartItem - m.DB.Begin failed", "error", err)
+		return queryError(ctx, err)
 	}
 	defer tx.Rollback()
 
@@ -293,7 +324,7 @@ func (m *CartItemModel) RemoveSingleCartItem(userID, productID int) error {
 
 	cartItem := CartItemSimplified{}
 
-	row := tx.QueryRow(selectQuery, userID, productID)
+	row := tx.QueryRowContext(ctx, selectQuery, userID, productID)
 	err = row.Scan(
 		&cartItem.ID,
 		&cartItem.UserID,
@@ -302,11 +333,11 @@ func (m *CartItemModel) RemoveSingleCartItem(userID, productID int) error {
 	)
 
 	if err == sql.ErrNoRows {
-		log.Printf("no cart item with the specified userID %d and productID %d exists.", userID, productID)
+		slog.InfoContext(ctx, "CartItemModel.RemoveSingleCartItem - no cart item for the product", "product_id", productID)
 		return ErrCartItemNotFound
 	} else if err != nil {
-		log.Printf("ERROR: CartItemModel.RemoveSingleCartItem - row.Scan: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.RemoveSingleCartItem - row.Scan", "error", err)
+		return queryError(ctx, err)
 	}
 
 	cartItem.Quantity--
@@ -316,58 +347,184 @@ func (m *CartItemModel) RemoveSingleCartItem(userID, productID int) error {
 			DELETE FROM cart_items
 			WHERE id = ? AND user_id = ?
 		`
-		_, err := tx.Exec(deleteQuery, cartItem.ID, cartItem.UserID)
+		_, err := tx.ExecContext(ctx, deleteQuery, cartItem.ID, cartItem.UserID)
 		if err != nil {
-			log.Printf("ERROR: CartItemModel.RemoveSingleCartItem - Delete Cart Item - m.DB.Exec: %v", err)
-			return err
+			slog.ErrorContext(ctx, "CartItemModel.RemoveSingleCartItem - Delete Cart Item - m.DB.Exec", "error", err)
+			return queryError(ctx, err)
 		}
 	} else {
 		updateQuery := `
 			UPDATE cart_items
 			SET 
-				cart_items.quantity = ?,
+				quantity = ?,
 				updated_at = ?
 			WHERE
 				cart_items.id = ? AND user_id = ?
 		`
-		_, err := tx.Exec(updateQuery, cartItem.Quantity, time.Now(), cartItem.ID, cartItem.UserID)
+		_, err := tx.ExecContext(ctx, updateQuery, cartItem.Quantity, time.Now(), cartItem.ID, cartItem.UserID)
 		if err != nil {
-			log.Printf("ERROR: CartItemModel.RemoveSingleCartItem - Update Cart Item - m.DB.Exec: %v", err)
-			return err
+			slog.ErrorContext(ctx, "CartItemModel.RemoveSingleCartItem - Update Cart Item - m.DB.Exec", "error", err)
+			return queryError(ctx, err)
 		}
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.RemoveSingleCartItem - tx.Commit failed: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.RemoveSingleCartItem - tx.Commit failed", "error", err)
+		return queryError(ctx, err)
 	}
 
 	return nil
 }
 
-func (m *CartItemModel) ClearCart(userID int) error {
+func (m *CartItemModel) ClearCart(ctx context.Context, userID int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	deleteQuery := `
 		DELETE FROM cart_items
 		WHERE user_id = ?
 	`
 
-	result, err := m.DB.Exec(deleteQuery, userID)
+	result, err := m.DB.ExecContext(ctx, deleteQuery, userID)
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.ClearCart = m.DB.Exec: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.ClearCart = m.DB.Exec", "error", err)
+		return queryError(ctx, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		log.Printf("ERROR: CartItemModel.ClearCart - result.RowsAffected: %v", err)
-		return err
+		slog.ErrorContext(ctx, "CartItemModel.ClearCart - result.RowsAffected", "error", err)
+		return queryError(ctx, err)
 	}
 
 	if rowsAffected == 0 {
-		log.Printf("no cart items were found for user ID: %d.", userID)
+		slog.InfoContext(ctx, "CartItemModel.ClearCart - no cart items found")
 		return ErrNoCartItemsFound
 	}
 
 	return nil
 }
+
+// SetCartItemQuantity sets the quantity of a product in the user's cart to an
+// absolute value, inserting or updating the row as needed. A quantity of 0
+// removes the product from the cart.
+func (m *CartItemModel) SetCartItemQuantity(ctx context.Context, userID, productID, quantity int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - m.DB.Begin failed", "error", err)
+		return queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: If the quantity is 0, delete the item and stop
+	// Step 2: Check that the product exists and has enough stock
+	// Step 3: Insert the item or update its quantity
+
+	// 1.
+	if quantity < 1 {
+		deleteQuery := `
+			DELETE FROM cart_items
+			WHERE user_id = ? AND product_id = ?
+		`
+		_, err := tx.ExecContext(ctx, deleteQuery, userID, productID)
+		if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - Delete Cart Item - tx.Exec", "error", err)
+			return queryError(ctx, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - tx.Commit failed", "error", err)
+			return queryError(ctx, err)
+		}
+		return nil
+	}
+
+	// 2.
+	productQuery := `
+		SELECT
+			p.title,
+			p.stock
+		FROM
+			products AS p
+		WHERE
+			p.id = ? AND p.archived_at IS NULL
+	`
+
+	var (
+		title string
+		stock int
+	)
+
+	err = tx.QueryRowContext(ctx, productQuery, productID).Scan(&title, &stock)
+	if err == sql.ErrNoRows {
+		slog.WarnContext(ctx, "CartItemModel.SetCartItemQuantity - product not found", "product_id", productID)
+		return ErrProductNotFound
+	} else if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - row.Scan", "error", err)
+		return queryError(ctx, err)
+	}
+
+	if quantity > stock {
+		return &InsufficientStockError{ProductID: productID, Title: title, Requested: quantity, Available: stock}
+	}
+
+	// 3.
+	now := time.Now()
+
+	updateQuery := `
+		UPDATE cart_items
+		SET
+			quantity = ?,
+			updated_at = ?
+		WHERE
+			cart_items.user_id = ? AND cart_items.product_id = ?
+	`
+	result, err := tx.ExecContext(ctx, updateQuery, quantity, now, userID, productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - Update Cart Item - tx.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - result.RowsAffected", "error", err)
+		return queryError(ctx, err)
+	}
+
+	// MySQL reports 0 affected rows when the quantity did not change, so check
+	// whether the row exists before inserting a new one.
+	if rowsAffected == 0 {
+		var cartItemID int
+
+		err = tx.QueryRowContext(ctx, `SELECT id FROM cart_items WHERE user_id = ? AND product_id = ?`, userID, productID).Scan(&cartItemID)
+		if err == sql.ErrNoRows {
+			insertQuery := `
+				INSERT INTO cart_items(
+					product_id, user_id, quantity, created_at, updated_at
+				) VALUES (?, ?, ?, ?, ?)
+			`
+			_, err = tx.ExecContext(ctx, insertQuery, productID, userID, quantity, now, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - Create Cart Item - tx.Exec", "error", err)
+				return queryError(ctx, err)
+			}
+		} else if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - row.Scan", "error", err)
+			return queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.SetCartItemQuantity - tx.Commit failed", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
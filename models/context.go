@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultQueryTimeout bounds a model call when the model has no QueryTimeout set.
+const DefaultQueryTimeout = 5 * time.Second
+
+// queryContext derives the context a model method runs its queries under, so
+// that a slow query is abandoned once the deadline passes or the caller goes
+// away. Methods that run a transaction share one deadline for all of it.
+// A zero timeout uses DefaultQueryTimeout and a negative one disables it.
+func queryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// queryError turns an error caused by a missed deadline into a *QueryTimeoutError.
+// Drivers report a deadline differently (MySQL returns the context error, SQLite
+// an interrupt), so the context itself is checked.
+func queryError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &QueryTimeoutError{Err: err}
+	}
+	return err
+}
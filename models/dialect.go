@@ -0,0 +1,40 @@
+package models
+
+// Dialect names the SQL database a model talks to. The models share one set of
+// queries and only differ where MySQL and SQLite disagree.
+type Dialect string
+
+const (
+	DialectMySQL  Dialect = "mysql"
+	DialectSQLite Dialect = "sqlite"
+)
+
+// IsValid reports whether d is one of the supported SQL dialects.
+func (d Dialect) IsValid() bool {
+	switch d {
+	case DialectMySQL, DialectSQLite:
+		return true
+	}
+	return false
+}
+
+// ForUpdate returns the clause that locks the selected rows until the end of the
+// transaction. SQLite has no row locks: a write transaction already locks the
+// whole database, so the clause is dropped.
+func (d Dialect) ForUpdate() string {
+	if d == DialectSQLite {
+		return ""
+	}
+	return "FOR UPDATE"
+}
+
+// AddClamped returns an expression adding a placeholder to column that stops at
+// zero instead of going negative. MySQL columns are INT UNSIGNED, where
+// column + ? with a result below zero fails with ERROR 1690 before any CASE can
+// clamp it, so the column is cast to a signed integer first.
+func (d Dialect) AddClamped(column string) string {
+	if d == DialectSQLite {
+		return "MAX(" + column + " + ?, 0)"
+	}
+	return "GREATEST(CAST(" + column + " AS SIGNED) + ?, 0)"
+}
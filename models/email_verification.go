@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// EmailVerification is a link sent to a new user to confirm their email. Only
+// the SHA-256 hash of its token is stored.
+type EmailVerification struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type EmailVerificationModel struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+func (m *EmailVerificationModel) CreateEmailVerification(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	stmt := `
+		INSERT INTO email_verifications (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err := m.DB.ExecContext(ctx, stmt, userID, tokenHash, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "EmailVerificationModel.CreateEmailVerification - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+func (m *EmailVerificationModel) LastEmailVerificationSentAt(ctx context.Context, userID int) (time.Time, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	var sentAt time.Time
+	query := `SELECT created_at FROM email_verifications WHERE user_id = ? ORDER BY created_at DESC LIMIT 1`
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&sentAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		slog.ErrorContext(ctx, "EmailVerificationModel.LastEmailVerificationSentAt - m.DB.QueryRow", "error", err)
+		return time.Time{}, queryError(ctx, err)
+	}
+
+	return sentAt, nil
+}
+
+func (m *EmailVerificationModel) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// 1. Find the user of the token, if it has not expired
+	// 2. Mark their email as verified, keeping the first time it was
+	// 3. Delete their links, which have served their purpose
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "EmailVerificationModel.VerifyEmail - m.DB.Begin", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	defer tx.Rollback()
+
+	// 1.
+	now := time.Now().UTC()
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id FROM email_verifications WHERE token_hash = ? AND expires_at > ?
+	`, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidEmailVerification
+		}
+		slog.ErrorContext(ctx, "EmailVerificationModel.VerifyEmail - tx.QueryRow", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	// 2.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET verified_at = ?, updated_at = ? WHERE id = ? AND verified_at IS NULL
+	`, now, now, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "EmailVerificationModel.VerifyEmail - tx.Exec update user", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	// 3.
+	_, err = tx.ExecContext(ctx, `DELETE FROM email_verifications WHERE user_id = ?`, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "EmailVerificationModel.VerifyEmail - tx.Exec delete tokens", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "EmailVerificationModel.VerifyEmail - tx.Commit", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	return userID, nil
+}
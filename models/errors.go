@@ -2,30 +2,96 @@ package models
 
 import (
 	"errors"
-	"strings"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 var (
 	// Common
 	ErrDuplicateRecord = errors.New("duplicate record found")
+	ErrQueryTimeout    = errors.New("database query timed out")
 	// Product
-	ErrProductNotFound = errors.New("product not found")
+	ErrProductNotFound      = errors.New("product not found")
+	ErrProductImageNotFound = errors.New("product image not found")
+	ErrInvalidImageOrder    = errors.New("image order must list every image of the product exactly once")
+	ErrInsufficientStock    = errors.New("insufficient stock")
 	// Cart
 	ErrCartItemNotFound = errors.New("cart item not found")
 	ErrNoCartItemsFound = errors.New("no cart items found")
+	// Order
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
 	// User
 	ErrInvalidCredentials = errors.New("invalid authentication credentials")
 	ErrDuplicateEmail     = errors.New("duplicate email")
 	ErrDuplicateUsername  = errors.New("duplicate username")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailNotVerified   = errors.New("please verify your email address before checking out, the link is in the email we sent you")
+	// Refresh Token
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	// Email Verification
+	ErrInvalidEmailVerification = errors.New("this email verification link is invalid or has expired")
+	// Password Reset
+	ErrInvalidPasswordReset = errors.New("this password reset link is invalid or has expired")
 )
 
+// InsufficientStockError is returned when a cart or an order asks for more units
+// of a product than are in stock. It matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	ProductID int
+	Title     string
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	if e.Available < 1 {
+		return fmt.Sprintf("%s is out of stock", e.Title)
+	}
+	return fmt.Sprintf("only %d of %s left in stock, %d requested", e.Available, e.Title, e.Requested)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// QueryTimeoutError is returned when a query does not finish before the model's
+// QueryTimeout or the caller's deadline. It matches ErrQueryTimeout with errors.Is
+// and wraps the driver error.
+type QueryTimeoutError struct {
+	Err error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return ErrQueryTimeout.Error()
+}
+
+func (e *QueryTimeoutError) Is(target error) bool {
+	return target == ErrQueryTimeout
+}
+
+func (e *QueryTimeoutError) Unwrap() error {
+	return e.Err
+}
+
 // IsDuplicateEntryError is a helper function to check for duplicate entry errors.
-// This example is for MySQL; error codes differ for other databases (e.g., PostgreSQL).
+// It understands the errors of every supported SQL driver.
 func IsDuplicateEntryError(err error) bool {
 	// MySQL's duplicate entry error code is 1062.
-	// You might need to import "github.com/go-sql-driver/mysql" if you are using MySQL.
-	// And then cast the error to *mysql.MySQLError.
-	// For now, a simplified check.
-	// A more robust check might involve parsing the error message or specific error types.
-	return strings.Contains(err.Error(), "Duplicate entry") || strings.Contains(err.Error(), "1062")
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+
+	// SQLite reports unique and primary key violations as extended constraint codes.
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+
+	return false
 }
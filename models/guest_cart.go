@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GuestCart is the cart of an anonymous visitor. It lives in the visitor's
+// session instead of cart_items and maps product IDs to quantities.
+type GuestCart map[int]int
+
+// ProductIDs returns the IDs of the products in the cart in ascending order.
+func (c GuestCart) ProductIDs() []int {
+	ids := make([]int, 0, len(c))
+	for productID := range c {
+		ids = append(ids, productID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// GetGuestCartItems loads the products of a guest cart and returns them as cart
+// items. Guest cart items have no row of their own, so their ID is the product ID.
+// Products that no longer exist or have been archived are left out.
+func (m *CartItemModel) GetGuestCartItems(ctx context.Context, cart GuestCart) ([]*CartItem, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	cartItems := make([]*CartItem, 0, len(cart))
+	if len(cart) == 0 {
+		return cartItems, nil
+	}
+
+	productIDs := cart.ProductIDs()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(productIDs)), ", ")
+	args := make([]any, len(productIDs))
+	for i, productID := range productIDs {
+		args[i] = productID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			p.id,
+			p.title,
+			p.category,
+			p.description,
+			p.price,
+			p.thumbnail,
+			p.stock,
+			p.updated_at
+		FROM
+			products AS p
+		WHERE
+			p.id IN (%s) AND p.archived_at IS NULL
+		ORDER BY p.id DESC
+	`, placeholders)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.GetGuestCartItems - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cartItem := CartItem{}
+		cartItem.Product = &CartItemProduct{}
+
+		err := rows.Scan(
+			&cartItem.Product.ID,
+			&cartItem.Product.Title,
+			&cartItem.Product.Category,
+			&cartItem.Product.Description,
+			&cartItem.Product.Price,
+			&cartItem.Product.Thumbnail,
+			&cartItem.Product.Stock,
+			&cartItem.UpdatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.GetGuestCartItems - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		cartItem.ID = cartItem.Product.ID
+		cartItem.ProductID = cartItem.Product.ID
+		cartItem.Quantity = cart[cartItem.ProductID]
+		cartItem.CreatedAt = cartItem.UpdatedAt
+
+		cartItems = append(cartItems, &cartItem)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.GetGuestCartItems - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return cartItems, nil
+}
+
+// MaxCartItemQuantity is the most units of one product a cart line may hold,
+// the same limit SetCartQuantity enforces on requests.
+const MaxCartItemQuantity = 100
+
+// ReducedCartItem is a line of a merged guest cart that was cut down because
+// the summed quantity was more than the stock left or MaxCartItemQuantity.
+type ReducedCartItem struct {
+	ProductID int
+	Title     string
+	Requested int
+	Quantity  int
+}
+
+// MergeGuestCart moves a guest cart into the user's cart in a single transaction.
+// Quantities of products already in the user's cart are summed and capped at the
+// stock left and MaxCartItemQuantity; the lines that were capped are returned.
+// Products that no longer exist or have been archived are dropped.
+func (m *CartItemModel) MergeGuestCart(ctx context.Context, userID int, cart GuestCart) ([]*ReducedCartItem, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.MergeGuestCart - m.DB.Begin failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// For every product in the guest cart:
+	// Step 1: Skip it if the product is gone, otherwise read its stock
+	// Step 2: Sum the quantity with the existing cart item and cap it
+	// Step 3: Update the existing cart item, or insert a new one, or delete
+	//         the line when nothing is left in stock
+
+	productQuery := `SELECT title, stock FROM products WHERE id = ? AND archived_at IS NULL`
+	cartItemQuery := `SELECT id, quantity FROM cart_items WHERE user_id = ? AND product_id = ?`
+	updateQuery := `
+		UPDATE cart_items
+		SET
+			quantity = ?,
+			updated_at = ?
+		WHERE
+			cart_items.id = ?
+	`
+	insertQuery := `
+		INSERT INTO cart_items(
+			product_id, user_id, quantity, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?)
+	`
+	deleteQuery := `DELETE FROM cart_items WHERE id = ?`
+
+	now := time.Now()
+	reduced := make([]*ReducedCartItem, 0)
+
+	for _, productID := range cart.ProductIDs() {
+		quantity := cart[productID]
+		if quantity < 1 {
+			continue
+		}
+
+		// 1.
+		var (
+			title string
+			stock int
+		)
+		err := tx.QueryRowContext(ctx, productQuery, productID).Scan(&title, &stock)
+		if err == sql.ErrNoRows {
+			slog.WarnContext(ctx, "CartItemModel.MergeGuestCart - dropping product from the guest cart: product not found", "product_id", productID)
+			continue
+		} else if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.MergeGuestCart - Get Product - row.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		// 2.
+		var cartItemID, existing int
+		err = tx.QueryRowContext(ctx, cartItemQuery, userID, productID).Scan(&cartItemID, &existing)
+		if err != nil && err != sql.ErrNoRows {
+			slog.ErrorContext(ctx, "CartItemModel.MergeGuestCart - Get Cart Item - row.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		requested := existing + quantity
+		merged := min(requested, stock, MaxCartItemQuantity)
+		if merged < requested {
+			reduced = append(reduced, &ReducedCartItem{ProductID: productID, Title: title, Requested: requested, Quantity: merged})
+		}
+
+		// 3.
+		switch {
+		case cartItemID > 0 && merged < 1:
+			_, err = tx.ExecContext(ctx, deleteQuery, cartItemID)
+		case cartItemID > 0:
+			_, err = tx.ExecContext(ctx, updateQuery, merged, now, cartItemID)
+		case merged > 0:
+			_, err = tx.ExecContext(ctx, insertQuery, productID, userID, merged, now, now)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "CartItemModel.MergeGuestCart - Save Cart Item - tx.Exec", "error", err)
+			return nil, queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "CartItemModel.MergeGuestCart - tx.Commit failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return reduced, nil
+}
@@ -0,0 +1,175 @@
+package models_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+)
+
+func TestMergeGuestCart(t *testing.T) {
+	// The products are created in this order, so their IDs are 1 to 4
+	const (
+		waffle   = 1 // stock 10
+		brownie  = 2 // stock 3
+		macaron  = 3 // stock 500
+		archived = 4 // stock 10, archived
+		missing  = 99
+	)
+
+	tests := []struct {
+		name        string
+		userCart    map[int]int // already in the user's cart
+		guestCart   models.GuestCart
+		wantCart    map[int]int
+		wantReduced []models.ReducedCartItem
+	}{
+		{
+			name:      "into an empty cart",
+			guestCart: models.GuestCart{waffle: 2, brownie: 1},
+			wantCart:  map[int]int{waffle: 2, brownie: 1},
+		},
+		{
+			name:      "summed with an existing line",
+			userCart:  map[int]int{waffle: 3},
+			guestCart: models.GuestCart{waffle: 2},
+			wantCart:  map[int]int{waffle: 5},
+		},
+		{
+			name:        "capped at the stock",
+			userCart:    map[int]int{brownie: 2},
+			guestCart:   models.GuestCart{brownie: 2, waffle: 1},
+			wantCart:    map[int]int{brownie: 3, waffle: 1},
+			wantReduced: []models.ReducedCartItem{{ProductID: brownie, Title: "Product 2", Requested: 4, Quantity: 3}},
+		},
+		{
+			name:        "capped at the cart maximum",
+			userCart:    map[int]int{macaron: 60},
+			guestCart:   models.GuestCart{macaron: 60},
+			wantCart:    map[int]int{macaron: models.MaxCartItemQuantity},
+			wantReduced: []models.ReducedCartItem{{ProductID: macaron, Title: "Product 3", Requested: 120, Quantity: models.MaxCartItemQuantity}},
+		},
+		{
+			name:      "missing and archived products are dropped",
+			guestCart: models.GuestCart{missing: 1, archived: 1, waffle: 1},
+			wantCart:  map[int]int{waffle: 1},
+		},
+		{
+			name:      "empty lines are skipped",
+			guestCart: models.GuestCart{waffle: 0},
+			wantCart:  map[int]int{},
+		},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := s.open(t)
+
+				userID := createUser(t, repo, "bob@example.com")
+				for i, stock := range []int{10, 3, 500, 10} {
+					product, err := repo.CreateProduct(ctx, &models.ProductInput{
+						Title:    fmt.Sprintf("Product %d", i+1),
+						Category: "Cake",
+						Price:    money.MustParse("6.50", money.DefaultCurrency),
+						Stock:    stock,
+					})
+					if err != nil {
+						t.Fatalf("CreateProduct: %v", err)
+					}
+					if product.ID != i+1 {
+						t.Fatalf("product %d got ID %d", i+1, product.ID)
+					}
+				}
+				if _, err := repo.SetProductArchived(ctx, archived, true); err != nil {
+					t.Fatalf("SetProductArchived: %v", err)
+				}
+				for productID, quantity := range tt.userCart {
+					if err := repo.SetCartItemQuantity(ctx, userID, productID, quantity); err != nil {
+						t.Fatalf("SetCartItemQuantity: %v", err)
+					}
+				}
+
+				reduced, err := repo.MergeGuestCart(ctx, userID, tt.guestCart)
+				if err != nil {
+					t.Fatalf("MergeGuestCart: %v", err)
+				}
+
+				gotReduced := make([]models.ReducedCartItem, len(reduced))
+				for i, item := range reduced {
+					gotReduced[i] = *item
+				}
+				if len(gotReduced) > 0 || len(tt.wantReduced) > 0 {
+					if !reflect.DeepEqual(gotReduced, tt.wantReduced) {
+						t.Errorf("reduced = %+v, want %+v", gotReduced, tt.wantReduced)
+					}
+				}
+
+				items, err := repo.GetCartItems(ctx, userID)
+				if err != nil {
+					t.Fatalf("GetCartItems: %v", err)
+				}
+				gotCart := map[int]int{}
+				for _, item := range items {
+					gotCart[item.ProductID] = item.Quantity
+				}
+				if !reflect.DeepEqual(gotCart, tt.wantCart) {
+					t.Errorf("cart = %v, want %v", gotCart, tt.wantCart)
+				}
+			})
+		}
+	}
+}
+
+// TestMergeGuestCartIsOneTransaction makes the last line of a merge fail and
+// checks that the lines before it were rolled back with it.
+func TestMergeGuestCartIsOneTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	users := &models.UserModel{DB: db}
+	products := &models.ProductModel{DB: db, Dialect: models.DialectSQLite}
+	cart := &models.CartItemModel{DB: db}
+
+	userID := createUser(t, store{UserRepository: users}, "bob@example.com")
+	var productIDs []int
+	for _, title := range []string{"Waffle", "Brownie"} {
+		product, err := products.CreateProduct(ctx, &models.ProductInput{
+			Title:    title,
+			Category: "Cake",
+			Price:    money.MustParse("6.50", money.DefaultCurrency),
+			Stock:    10,
+		})
+		if err != nil {
+			t.Fatalf("CreateProduct: %v", err)
+		}
+		productIDs = append(productIDs, product.ID)
+	}
+
+	// Lines are merged in product ID order, so the brownie comes last
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TRIGGER fail_merge BEFORE INSERT ON cart_items
+		WHEN NEW.product_id = %d
+		BEGIN
+			SELECT RAISE(ABORT, 'merge failed');
+		END
+	`, productIDs[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cart.MergeGuestCart(ctx, userID, models.GuestCart{productIDs[0]: 1, productIDs[1]: 1}); err == nil {
+		t.Fatal("MergeGuestCart() succeeded, want the trigger's error")
+	}
+
+	items, err := cart.GetCartItems(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetCartItems: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("cart has %d items after a failed merge, want none", len(items))
+	}
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func toCartItemProduct(p *models.Product) *models.CartItemProduct {
+	return &models.CartItemProduct{
+		ID:          p.ID,
+		Title:       p.Title,
+		Category:    p.Category,
+		Description: p.Description,
+		Price:       p.Price,
+		Thumbnail:   p.Thumbnail,
+		Stock:       p.Stock,
+	}
+}
+
+// findCartItem returns the cart item of the user for a product. The caller must hold s.mu.
+func (s *Store) findCartItem(userID, productID int) *models.CartItem {
+	for _, item := range s.cartItems {
+		if item.UserID == userID && item.ProductID == productID {
+			return item
+		}
+	}
+	return nil
+}
+
+// userCartItems returns the cart items of a user, oldest first. The caller must hold s.mu.
+func (s *Store) userCartItems(userID int) []*models.CartItem {
+	items := make([]*models.CartItem, 0)
+	for _, item := range s.cartItems {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+
+	return items
+}
+
+// availableProduct returns a product that can be added to a cart. The caller must hold s.mu.
+func (s *Store) availableProduct(productID int) (*models.Product, error) {
+	p, ok := s.products[productID]
+	if !ok || p.IsArchived() {
+		return nil, models.ErrProductNotFound
+	}
+	return p, nil
+}
+
+// setCartItemQuantity inserts or updates the cart item of the user for a
+// product. The caller must hold s.mu.
+func (s *Store) setCartItemQuantity(userID, productID, quantity int, now time.Time) {
+	if item := s.findCartItem(userID, productID); item != nil {
+		item.Quantity = quantity
+		item.UpdatedAt = now
+		return
+	}
+
+	id := s.nextID("cart_items")
+	s.cartItems[id] = &models.CartItem{
+		ID:        id,
+		ProductID: productID,
+		Quantity:  quantity,
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (s *Store) GetCartItems(_ context.Context, userID int) ([]*models.CartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.userCartItems(userID)
+
+	// Newest first, like the SQL models
+	cartItems := make([]*models.CartItem, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		cartItem := *items[i]
+		cartItem.Product = &models.CartItemProduct{}
+		if p, ok := s.products[cartItem.ProductID]; ok {
+			cartItem.Product = toCartItemProduct(p)
+		}
+		cartItems = append(cartItems, &cartItem)
+	}
+
+	return cartItems, nil
+}
+
+func (s *Store) AddCartItem(_ context.Context, userID, productID, quantity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.availableProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	if item := s.findCartItem(userID, productID); item != nil {
+		current = item.Quantity
+	}
+
+	if current+quantity > p.Stock {
+		return &models.InsufficientStockError{ProductID: productID, Title: p.Title, Requested: current + quantity, Available: p.Stock}
+	}
+
+	s.setCartItemQuantity(userID, productID, current+quantity, time.Now())
+
+	return nil
+}
+
+func (s *Store) RemoveCartItem(_ context.Context, userID int, cartItemID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.cartItems[cartItemID]
+	if !ok || item.UserID != userID {
+		return models.ErrCartItemNotFound
+	}
+	delete(s.cartItems, cartItemID)
+
+	return nil
+}
+
+func (s *Store) RemoveSingleCartItem(_ context.Context, userID, productID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.findCartItem(userID, productID)
+	if item == nil {
+		return models.ErrCartItemNotFound
+	}
+
+	item.Quantity--
+	item.UpdatedAt = time.Now()
+	if item.Quantity < 1 {
+		delete(s.cartItems, item.ID)
+	}
+
+	return nil
+}
+
+func (s *Store) ClearCart(_ context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.userCartItems(userID)
+	if len(items) == 0 {
+		return models.ErrNoCartItemsFound
+	}
+	for _, item := range items {
+		delete(s.cartItems, item.ID)
+	}
+
+	return nil
+}
+
+func (s *Store) SetCartItemQuantity(_ context.Context, userID, productID, quantity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if quantity < 1 {
+		if item := s.findCartItem(userID, productID); item != nil {
+			delete(s.cartItems, item.ID)
+		}
+		return nil
+	}
+
+	p, err := s.availableProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	if quantity > p.Stock {
+		return &models.InsufficientStockError{ProductID: productID, Title: p.Title, Requested: quantity, Available: p.Stock}
+	}
+
+	s.setCartItemQuantity(userID, productID, quantity, time.Now())
+
+	return nil
+}
+
+func (s *Store) GetGuestCartItems(_ context.Context, cart models.GuestCart) ([]*models.CartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	productIDs := cart.ProductIDs()
+
+	// Highest product ID first, like the SQL models
+	cartItems := make([]*models.CartItem, 0, len(productIDs))
+	for i := len(productIDs) - 1; i >= 0; i-- {
+		p, err := s.availableProduct(productIDs[i])
+		if err != nil {
+			continue
+		}
+
+		cartItems = append(cartItems, &models.CartItem{
+			ID:        p.ID,
+			ProductID: p.ID,
+			Quantity:  cart[p.ID],
+			CreatedAt: p.UpdatedAt,
+			UpdatedAt: p.UpdatedAt,
+			Product:   toCartItemProduct(p),
+		})
+	}
+
+	return cartItems, nil
+}
+
+func (s *Store) MergeGuestCart(_ context.Context, userID int, cart models.GuestCart) ([]*models.ReducedCartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	reduced := make([]*models.ReducedCartItem, 0)
+
+	for _, productID := range cart.ProductIDs() {
+		quantity := cart[productID]
+		if quantity < 1 {
+			continue
+		}
+
+		p, err := s.availableProduct(productID)
+		if err != nil {
+			continue
+		}
+
+		item := s.findCartItem(userID, productID)
+		requested := quantity
+		if item != nil {
+			requested += item.Quantity
+		}
+
+		merged := min(requested, p.Stock, models.MaxCartItemQuantity)
+		if merged < requested {
+			reduced = append(reduced, &models.ReducedCartItem{ProductID: productID, Title: p.Title, Requested: requested, Quantity: merged})
+		}
+
+		switch {
+		case item != nil && merged < 1:
+			delete(s.cartItems, item.ID)
+		case item != nil || merged > 0:
+			s.setCartItemQuantity(userID, productID, merged, now)
+		}
+	}
+
+	return reduced, nil
+}
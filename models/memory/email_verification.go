@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func (s *Store) CreateEmailVerification(_ context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("email_verifications")
+	s.emailVerifications[id] = &models.EmailVerification{
+		ID:        id,
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt.UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (s *Store) LastEmailVerificationSentAt(_ context.Context, userID int) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sentAt time.Time
+	for _, v := range s.emailVerifications {
+		if v.UserID == userID && v.CreatedAt.After(sentAt) {
+			sentAt = v.CreatedAt
+		}
+	}
+	return sentAt, nil
+}
+
+func (s *Store) VerifyEmail(_ context.Context, tokenHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	var verification *models.EmailVerification
+	for _, v := range s.emailVerifications {
+		if v.TokenHash == tokenHash {
+			verification = v
+			break
+		}
+	}
+	if verification == nil || !verification.ExpiresAt.After(now) {
+		return 0, models.ErrInvalidEmailVerification
+	}
+
+	if user, ok := s.users[verification.UserID]; ok && user.VerifiedAt == nil {
+		user.VerifiedAt = &now
+		user.UpdatedAt = now
+	}
+
+	for id, v := range s.emailVerifications {
+		if v.UserID == verification.UserID {
+			delete(s.emailVerifications, id)
+		}
+	}
+	return verification.UserID, nil
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+)
+
+// orderCopy returns a copy of a stored order. Its items are copied too unless
+// withItems is false, in which case Items is empty. The caller must hold s.mu.
+func orderCopy(o *models.Order, withItems bool) *models.Order {
+	order := *o
+	order.Items = []*models.OrderItem{}
+	order.History = nil
+
+	if withItems {
+		for _, item := range o.Items {
+			itemCopy := *item
+			order.Items = append(order.Items, &itemCopy)
+		}
+	}
+
+	return &order
+}
+
+// recordStatusChange appends a row to the status history. The caller must hold s.mu.
+func (s *Store) recordStatusChange(orderID int, from *models.OrderStatus, to models.OrderStatus, changedBy *int, at time.Time) {
+	s.orderHistory = append(s.orderHistory, &models.OrderStatusChange{
+		ID:         s.nextID("order_status_history"),
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		CreatedAt:  at,
+	})
+}
+
+func (s *Store) CreateOrderFromCart(_ context.Context, userID int) (*models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Step 1: Read the cart together with the current product title, price and stock
+	// Step 2: Store the order and its items and take them out of stock
+	// Step 3: Record the initial status
+	// Step 4: Clear the cart
+
+	// 1.
+	now := time.Now()
+	order := &models.Order{
+		UserID:     userID,
+		Status:     models.OrderStatusPending,
+		Currency:   money.DefaultCurrency,
+		TotalPrice: money.Zero(money.DefaultCurrency),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Items:      []*models.OrderItem{},
+	}
+
+	cartItems := s.userCartItems(userID)
+	sort.Slice(cartItems, func(i, j int) bool {
+		return cartItems[i].ProductID < cartItems[j].ProductID
+	})
+
+	for _, cartItem := range cartItems {
+		p, ok := s.products[cartItem.ProductID]
+		if !ok {
+			continue
+		}
+
+		if cartItem.Quantity > p.Stock {
+			return nil, &models.InsufficientStockError{ProductID: p.ID, Title: p.Title, Requested: cartItem.Quantity, Available: p.Stock}
+		}
+
+		item := &models.OrderItem{
+			ProductID:    p.ID,
+			ProductTitle: p.Title,
+			UnitPrice:    p.Price,
+			Quantity:     cartItem.Quantity,
+			TotalPrice:   p.Price.Multiply(cartItem.Quantity),
+			CreatedAt:    now,
+		}
+
+		order.TotalPrice = order.TotalPrice.Add(item.TotalPrice)
+		order.TotalQuantity += item.Quantity
+		order.Items = append(order.Items, item)
+	}
+
+	if len(order.Items) == 0 {
+		return nil, models.ErrNoCartItemsFound
+	}
+
+	// 2.
+	order.ID = s.nextID("orders")
+	for _, item := range order.Items {
+		item.ID = s.nextID("order_items")
+		item.OrderID = order.ID
+		s.products[item.ProductID].Stock -= item.Quantity
+	}
+	s.orders[order.ID] = order
+
+	// 3.
+	s.recordStatusChange(order.ID, nil, order.Status, &userID, now)
+
+	// 4.
+	for _, cartItem := range cartItems {
+		delete(s.cartItems, cartItem.ID)
+	}
+
+	return orderCopy(order, true), nil
+}
+
+// sortedOrders returns the stored orders accepted by match, oldest first.
+// The caller must hold s.mu.
+func (s *Store) sortedOrders(match func(o *models.Order) bool) []*models.Order {
+	orders := make([]*models.Order, 0)
+	for _, o := range s.orders {
+		if match(o) {
+			orders = append(orders, o)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders
+}
+
+func (s *Store) GetOrdersByUser(_ context.Context, userID, limit, offset int) ([]*models.Order, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userOrders := s.sortedOrders(func(o *models.Order) bool {
+		return o.UserID == userID
+	})
+	total := len(userOrders)
+
+	// Newest first, one page at a time
+	orders := make([]*models.Order, 0)
+	for i := total - 1 - offset; i >= 0 && len(orders) < limit; i-- {
+		orders = append(orders, orderCopy(userOrders[i], false))
+	}
+
+	return orders, total, nil
+}
+
+func (s *Store) GetOrderByID(_ context.Context, userID, orderID int) (*models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || o.UserID != userID {
+		return nil, models.ErrOrderNotFound
+	}
+
+	return orderCopy(o, true), nil
+}
+
+func (s *Store) UpdateOrderStatus(_ context.Context, orderID int, ownerID *int, to models.OrderStatus, changedBy *int) (*models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderID]
+	if !ok || (ownerID != nil && o.UserID != *ownerID) {
+		return nil, models.ErrOrderNotFound
+	}
+
+	current := o.Status
+	allowed := current.CanTransitionTo(to)
+	if ownerID != nil {
+		allowed = current.CustomerCanTransitionTo(to)
+	}
+	if !allowed {
+		return nil, &models.InvalidStatusTransitionError{From: current, To: to}
+	}
+
+	now := time.Now()
+	o.Status = to
+	o.UpdatedAt = now
+	s.recordStatusChange(orderID, &current, to, changedBy, now)
+
+	// Return the items of a cancelled order to stock
+	if to == models.OrderStatusCancelled {
+		for _, item := range o.Items {
+			if p, ok := s.products[item.ProductID]; ok {
+				p.Stock += item.Quantity
+			}
+		}
+	}
+
+	return &models.Order{ID: orderID, Status: to, UpdatedAt: now}, nil
+}
+
+func (s *Store) GetOrderStatusHistory(_ context.Context, orderID int) ([]*models.OrderStatusChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := make([]*models.OrderStatusChange, 0)
+	for _, change := range s.orderHistory {
+		if change.OrderID == orderID {
+			changeCopy := *change
+			history = append(history, &changeCopy)
+		}
+	}
+
+	return history, nil
+}
+
+func (s *Store) GetOrdersByStatus(_ context.Context, statuses []models.OrderStatus) ([]*models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[models.OrderStatus]bool, len(statuses))
+	for _, status := range statuses {
+		wanted[status] = true
+	}
+
+	orders := make([]*models.Order, 0)
+	for _, o := range s.sortedOrders(func(o *models.Order) bool { return wanted[o.Status] }) {
+		orders = append(orders, orderCopy(o, true))
+	}
+
+	return orders, nil
+}
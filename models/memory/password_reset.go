@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func (s *Store) CreatePasswordReset(_ context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("password_resets")
+	s.passwordResets[id] = &models.PasswordReset{
+		ID:        id,
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt.UTC(),
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (s *Store) LastPasswordResetSentAt(_ context.Context, userID int) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sentAt time.Time
+	for _, r := range s.passwordResets {
+		if r.UserID == userID && r.CreatedAt.After(sentAt) {
+			sentAt = r.CreatedAt
+		}
+	}
+	return sentAt, nil
+}
+
+func (s *Store) ResetPassword(_ context.Context, tokenHash, password string) (int, error) {
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	var reset *models.PasswordReset
+	for _, r := range s.passwordResets {
+		if r.TokenHash == tokenHash {
+			reset = r
+			break
+		}
+	}
+	if reset == nil || reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+		return 0, models.ErrInvalidPasswordReset
+	}
+
+	if user, ok := s.users[reset.UserID]; ok {
+		user.Hash = string(hashPassword)
+		user.UpdatedAt = now
+	}
+
+	// Every other link of the user stops working too
+	for _, r := range s.passwordResets {
+		if r.UserID == reset.UserID && r.UsedAt == nil {
+			r.UsedAt = &now
+		}
+	}
+	return reset.UserID, nil
+}
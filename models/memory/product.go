@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+// sortedImages returns the images of a product in display order. The caller must hold s.mu.
+func (s *Store) sortedImages(productID int) []*models.ProductImage {
+	images := make([]*models.ProductImage, 0)
+	for _, image := range s.productImages {
+		if image.ProductID == productID {
+			images = append(images, image)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Position != images[j].Position {
+			return images[i].Position < images[j].Position
+		}
+		return images[i].ID < images[j].ID
+	})
+
+	return images
+}
+
+// productCopy returns a copy of a stored product with its images filled in.
+// The caller must hold s.mu.
+func (s *Store) productCopy(p *models.Product) *models.Product {
+	product := *p
+	if p.ArchivedAt != nil {
+		archivedAt := *p.ArchivedAt
+		product.ArchivedAt = &archivedAt
+	}
+
+	product.Images = []string{}
+	for _, image := range s.sortedImages(p.ID) {
+		product.Images = append(product.Images, image.Image)
+	}
+
+	return &product
+}
+
+// productByTitle finds a product by title regardless of case, like the MySQL
+// collation. The caller must hold s.mu.
+func (s *Store) productByTitle(title string) *models.Product {
+	for _, p := range s.products {
+		if strings.EqualFold(p.Title, title) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Store) getProducts(includeArchived bool) []*models.Product {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	products := []*models.Product{}
+	for _, p := range s.products {
+		if includeArchived || !p.IsArchived() {
+			products = append(products, s.productCopy(p))
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+
+	return products
+}
+
+func (s *Store) GetAllProducts(_ context.Context) ([]*models.Product, error) {
+	return s.getProducts(false), nil
+}
+
+func (s *Store) GetAllProductsIncludingArchived(_ context.Context) ([]*models.Product, error) {
+	return s.getProducts(true), nil
+}
+
+func (s *Store) GetProductByID(_ context.Context, productID int) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.products[productID]
+	if !ok {
+		return nil, models.ErrProductNotFound
+	}
+
+	return s.productCopy(p), nil
+}
+
+func (s *Store) CreateProduct(_ context.Context, input *models.ProductInput) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.productByTitle(input.Title) != nil {
+		return nil, models.ErrDuplicateRecord
+	}
+
+	p := s.insertProduct(input, time.Now())
+
+	return s.productCopy(p), nil
+}
+
+// insertProduct stores a new product. The caller must hold s.mu.
+func (s *Store) insertProduct(input *models.ProductInput, now time.Time) *models.Product {
+	id := s.nextID("products")
+	p := &models.Product{ID: id, CreatedAt: now}
+	setProductInput(p, input, now)
+	s.products[id] = p
+	return p
+}
+
+func setProductInput(p *models.Product, input *models.ProductInput, now time.Time) {
+	p.Title = input.Title
+	p.Category = input.Category
+	p.Description = input.Description
+	p.Price = input.Price
+	p.Thumbnail = input.Thumbnail
+	p.Stock = input.Stock
+	p.UpdatedAt = now
+}
+
+func (s *Store) UpdateProduct(_ context.Context, productID int, input *models.ProductInput) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if other := s.productByTitle(input.Title); other != nil && other.ID != productID {
+		return nil, models.ErrDuplicateRecord
+	}
+
+	p, ok := s.products[productID]
+	if !ok {
+		return nil, models.ErrProductNotFound
+	}
+
+	stock := max(p.Stock+input.StockChange, 0)
+	setProductInput(p, input, time.Now())
+	p.Stock = stock
+
+	return s.productCopy(p), nil
+}
+
+func (s *Store) SetProductArchived(_ context.Context, productID int, archived bool) (*models.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.products[productID]
+	if !ok {
+		return nil, models.ErrProductNotFound
+	}
+
+	now := time.Now()
+	p.ArchivedAt = nil
+	if archived {
+		p.ArchivedAt = &now
+	}
+	p.UpdatedAt = now
+
+	return s.productCopy(p), nil
+}
+
+func (s *Store) UpsertProduct(_ context.Context, input *models.ProductInput, images []string) (*models.Product, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	created := false
+
+	p := s.productByTitle(input.Title)
+	if p == nil {
+		p = s.insertProduct(input, now)
+		created = true
+	} else {
+		// The title is the lookup key, so only the other fields change
+		title := p.Title
+		setProductInput(p, input, now)
+		p.Title = title
+	}
+
+	for id, image := range s.productImages {
+		if image.ProductID == p.ID {
+			delete(s.productImages, id)
+		}
+	}
+	for position, image := range images {
+		id := s.nextID("product_images")
+		s.productImages[id] = &models.ProductImage{
+			ID:        id,
+			ProductID: p.ID,
+			Image:     image,
+			Position:  position,
+			CreatedAt: now,
+		}
+	}
+
+	return s.productCopy(p), created, nil
+}
+
+func (s *Store) ResetCatalog(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orderHistory = []*models.OrderStatusChange{}
+	clear(s.orders)
+	clear(s.cartItems)
+	clear(s.productImages)
+	clear(s.products)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func imageCopies(images []*models.ProductImage) []*models.ProductImage {
+	copies := make([]*models.ProductImage, len(images))
+	for i, image := range images {
+		imageCopy := *image
+		copies[i] = &imageCopy
+	}
+	return copies
+}
+
+func (s *Store) GetProductImages(_ context.Context, productID int) ([]*models.ProductImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return imageCopies(s.sortedImages(productID)), nil
+}
+
+func (s *Store) GetAllProductImages(_ context.Context) (map[int][]*models.ProductImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	images := make(map[int][]*models.ProductImage)
+	for productID := range s.products {
+		if productImages := s.sortedImages(productID); len(productImages) > 0 {
+			images[productID] = imageCopies(productImages)
+		}
+	}
+
+	return images, nil
+}
+
+func (s *Store) AddProductImage(_ context.Context, productID int, image string) (*models.ProductImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.products[productID]; !ok {
+		return nil, models.ErrProductNotFound
+	}
+
+	// Place the image after the existing ones
+	position := 1
+	for _, existing := range s.productImages {
+		if existing.ProductID == productID && existing.Position >= position {
+			position = existing.Position + 1
+		}
+	}
+
+	productImage := &models.ProductImage{
+		ID:        s.nextID("product_images"),
+		ProductID: productID,
+		Image:     image,
+		Position:  position,
+		CreatedAt: time.Now(),
+	}
+	s.productImages[productImage.ID] = productImage
+
+	imageCopy := *productImage
+	return &imageCopy, nil
+}
+
+func (s *Store) ReorderProductImages(_ context.Context, productID int, imageIDs []int) ([]*models.ProductImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.sortedImages(productID)
+
+	// The new order must be a permutation of the existing images
+	if len(imageIDs) != len(existing) {
+		return nil, models.ErrInvalidImageOrder
+	}
+	seen := make(map[int]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		image, ok := s.productImages[id]
+		if !ok || image.ProductID != productID || seen[id] {
+			return nil, models.ErrInvalidImageOrder
+		}
+		seen[id] = true
+	}
+
+	for index, id := range imageIDs {
+		s.productImages[id].Position = index + 1
+	}
+
+	return imageCopies(s.sortedImages(productID)), nil
+}
+
+func (s *Store) RemoveProductImage(_ context.Context, productID, imageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	image, ok := s.productImages[imageID]
+	if !ok || image.ProductID != productID {
+		return models.ErrProductImageNotFound
+	}
+	delete(s.productImages, imageID)
+
+	return nil
+}
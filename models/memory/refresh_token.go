@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func (s *Store) CreateRefreshToken(_ context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.insertRefreshToken(userID, familyID, tokenHash, expiresAt, time.Now().UTC())
+	return nil
+}
+
+// insertRefreshToken stores a new token. The caller must hold s.mu.
+func (s *Store) insertRefreshToken(userID int, familyID, tokenHash string, expiresAt, now time.Time) {
+	id := s.nextID("refresh_tokens")
+	s.refreshTokens[id] = &models.RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt.UTC(),
+		CreatedAt: now,
+	}
+}
+
+func (s *Store) RotateRefreshToken(_ context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var token *models.RefreshToken
+	for _, t := range s.refreshTokens {
+		if t.TokenHash == tokenHash {
+			token = t
+			break
+		}
+	}
+	if token == nil || token.RevokedAt != nil {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	now := time.Now().UTC()
+
+	// A rotated token coming back means it was copied: revoke its family
+	if token.RotatedAt != nil {
+		for _, t := range s.refreshTokens {
+			if t.FamilyID == token.FamilyID && t.RevokedAt == nil {
+				t.RevokedAt = &now
+			}
+		}
+		return nil, models.ErrRefreshTokenReused
+	}
+	if !token.ExpiresAt.After(now) {
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	token.RotatedAt = &now
+	s.insertRefreshToken(token.UserID, token.FamilyID, newTokenHash, expiresAt, now)
+
+	rotated := *token
+	return &rotated, nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(_ context.Context, userID int, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	familyID := ""
+	for _, t := range s.refreshTokens {
+		if t.TokenHash == tokenHash && t.UserID == userID {
+			familyID = t.FamilyID
+			break
+		}
+	}
+	if familyID == "" {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, t := range s.refreshTokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeUserRefreshTokens(_ context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, t := range s.refreshTokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
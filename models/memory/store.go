@@ -0,0 +1,62 @@
+// Package memory implements the model repositories in process memory. It needs
+// no database server, which makes it handy for local development and tests;
+// everything is lost when the process exits.
+package memory
+
+import (
+	"sync"
+
+	models "dessert-ordering-go-system/models"
+)
+
+// Store holds every table in maps guarded by a single mutex, so each method
+// behaves like a transaction of the SQL models. Records are copied on the way
+// in and out so callers never share memory with the store.
+type Store struct {
+	mu sync.Mutex
+
+	users              map[int]*models.User
+	products           map[int]*models.Product
+	productImages      map[int]*models.ProductImage
+	cartItems          map[int]*models.CartItem
+	orders             map[int]*models.Order
+	orderHistory       []*models.OrderStatusChange
+	refreshTokens      map[int]*models.RefreshToken
+	passwordResets     map[int]*models.PasswordReset
+	emailVerifications map[int]*models.EmailVerification
+
+	// sequences holds the last ID handed out per table, like AUTO_INCREMENT.
+	sequences map[string]int
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:              make(map[int]*models.User),
+		products:           make(map[int]*models.Product),
+		productImages:      make(map[int]*models.ProductImage),
+		cartItems:          make(map[int]*models.CartItem),
+		orders:             make(map[int]*models.Order),
+		orderHistory:       []*models.OrderStatusChange{},
+		refreshTokens:      make(map[int]*models.RefreshToken),
+		passwordResets:     make(map[int]*models.PasswordReset),
+		emailVerifications: make(map[int]*models.EmailVerification),
+		sequences:          make(map[string]int),
+	}
+}
+
+// nextID returns the next ID of table. The caller must hold s.mu.
+func (s *Store) nextID(table string) int {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+var (
+	_ models.UserRepository              = (*Store)(nil)
+	_ models.RefreshTokenRepository      = (*Store)(nil)
+	_ models.PasswordResetRepository     = (*Store)(nil)
+	_ models.EmailVerificationRepository = (*Store)(nil)
+	_ models.ProductRepository           = (*Store)(nil)
+	_ models.ProductImageRepository      = (*Store)(nil)
+	_ models.CartRepository              = (*Store)(nil)
+	_ models.OrderRepository             = (*Store)(nil)
+)
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func toUserData(u *models.User) *models.UserData {
+	return &models.UserData{
+		ID:         u.ID,
+		Username:   u.Username,
+		Email:      u.Email,
+		Role:       u.Role,
+		VerifiedAt: u.VerifiedAt,
+		CreatedAt:  u.CreatedAt,
+		UpdatedAt:  u.UpdatedAt,
+	}
+}
+
+func (s *Store) CreateUser(_ context.Context, username, email, password string) error {
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Usernames and emails are unique regardless of case, like the MySQL collation
+	for _, u := range s.users {
+		if strings.EqualFold(u.Username, username) || strings.EqualFold(u.Email, email) {
+			return models.ErrDuplicateRecord
+		}
+	}
+
+	now := time.Now().UTC()
+	id := s.nextID("users")
+	s.users[id] = &models.User{
+		ID:        id,
+		Username:  username,
+		Email:     email,
+		Hash:      string(hashPassword),
+		Role:      models.RoleCustomer,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return nil
+}
+
+// authenticate finds the user matching the given field and checks the password.
+func (s *Store) authenticate(match func(u *models.User) bool, password string) (*models.UserData, error) {
+	s.mu.Lock()
+	var user *models.User
+	for _, u := range s.users {
+		if match(u) {
+			user = u
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if user == nil {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(user.Hash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return nil, models.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to compare hash and password: %w", err)
+	}
+
+	return toUserData(user), nil
+}
+
+func (s *Store) AuthenticateByEmail(_ context.Context, email, password string) (*models.UserData, error) {
+	return s.authenticate(func(u *models.User) bool {
+		return strings.EqualFold(u.Email, email)
+	}, password)
+}
+
+func (s *Store) AuthenticateByUsername(_ context.Context, username, password string) (*models.UserData, error) {
+	return s.authenticate(func(u *models.User) bool {
+		return strings.EqualFold(u.Username, username)
+	}, password)
+}
+
+func (s *Store) GetUserByID(_ context.Context, userID int) (*models.UserData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	return toUserData(user), nil
+}
+
+func (s *Store) GetUserByEmail(_ context.Context, email string) (*models.UserData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, email) {
+			return toUserData(u), nil
+		}
+	}
+
+	return nil, models.ErrUserNotFound
+}
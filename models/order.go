@@ -0,0 +1,469 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	money "dessert-ordering-go-system/internal/money"
+	utils "dessert-ordering-go-system/internal/utils"
+)
+
+// Order is a placed order. Its items are snapshots of the cart taken at checkout.
+type Order struct {
+	ID            int                  `json:"id"`
+	UserID        int                  `json:"userId"`
+	Status        OrderStatus          `json:"status"`
+	Currency      string               `json:"currency"`
+	TotalPrice    money.Money          `json:"totalPrice"`
+	TotalQuantity int                  `json:"totalQuantity"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+	Items         []*OrderItem         `json:"items"`
+	History       []*OrderStatusChange `json:"history,omitempty"`
+}
+
+// OrderItem is a single line of an order. The product title and unit price are
+// copied from the products table when the order is placed so that later catalog
+// edits do not rewrite order history.
+type OrderItem struct {
+	ID           int         `json:"id"`
+	OrderID      int         `json:"orderId"`
+	ProductID    int         `json:"productId"`
+	ProductTitle string      `json:"productTitle"`
+	UnitPrice    money.Money `json:"unitPrice"`
+	Quantity     int         `json:"quantity"`
+	TotalPrice   money.Money `json:"totalPrice"`
+	CreatedAt    time.Time   `json:"createdAt"`
+}
+
+// OrderForDisplay is a variant of the Order struct designed for frontend display,
+// where the prices are formatted as strings.
+type OrderForDisplay struct {
+	ID            int                    `json:"id"`
+	Status        OrderStatus            `json:"status"`
+	TotalPrice    string                 `json:"totalPrice"`
+	TotalQuantity int                    `json:"totalQuantity"`
+	CreatedAt     time.Time              `json:"createdAt"`
+	Items         []*OrderItemForDisplay `json:"items"`
+	History       []*OrderStatusChange   `json:"history"`
+	CanCancel     bool                   `json:"canCancel"`
+	NextStatuses  []OrderStatus          `json:"nextStatuses"`
+}
+
+type OrderItemForDisplay struct {
+	ID           int    `json:"id"`
+	ProductID    int    `json:"productId"`
+	ProductTitle string `json:"productTitle"`
+	UnitPrice    string `json:"unitPrice"`
+	Quantity     int    `json:"quantity"`
+	TotalPrice   string `json:"totalPrice"`
+}
+
+func NewOrderForDisplay(o *Order) *OrderForDisplay {
+	if o == nil {
+		return nil
+	}
+
+	items := make([]*OrderItemForDisplay, len(o.Items))
+	for i, item := range o.Items {
+		items[i] = &OrderItemForDisplay{
+			ID:           item.ID,
+			ProductID:    item.ProductID,
+			ProductTitle: item.ProductTitle,
+			UnitPrice:    utils.FormatPrice(item.UnitPrice),
+			Quantity:     item.Quantity,
+			TotalPrice:   utils.FormatPrice(item.TotalPrice),
+		}
+	}
+
+	return &OrderForDisplay{
+		ID:            o.ID,
+		Status:        o.Status,
+		TotalPrice:    utils.FormatPrice(o.TotalPrice),
+		TotalQuantity: o.TotalQuantity,
+		CreatedAt:     o.CreatedAt,
+		Items:         items,
+		History:       o.History,
+		CanCancel:     o.Status.CustomerCanCancel(),
+		NextStatuses:  o.Status.NextStatuses(),
+	}
+}
+
+// NewOrdersForDisplay converts a slice of models.Order pointers
+// to a slice of OrderForDisplay pointers.
+func NewOrdersForDisplay(orders []*Order) []*OrderForDisplay {
+	if orders == nil {
+		return nil
+	}
+
+	displayOrders := make([]*OrderForDisplay, len(orders))
+	for i, o := range orders {
+		displayOrders[i] = NewOrderForDisplay(o)
+	}
+	return displayOrders
+}
+
+type OrderModel struct {
+	DB           *sql.DB
+	Dialect      Dialect
+	QueryTimeout time.Duration
+}
+
+// CreateOrderFromCart turns the user's cart into an order, takes the ordered
+// quantities out of stock and empties the cart, all inside a single transaction.
+// The product rows are locked while the stock is checked so that two concurrent
+// checkouts cannot both sell the last unit.
+func (m *OrderModel) CreateOrderFromCart(ctx context.Context, userID int) (*Order, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - m.DB.Begin failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: Read and lock the cart together with the current product title, price and stock
+	// Step 2: Insert the order
+	// Step 3: Insert the order items and take them out of stock
+	// Step 4: Record the initial status
+	// Step 5: Clear the cart
+
+	// 1.
+	// Rows are locked in product order so that concurrent checkouts always take
+	// the locks in the same order and cannot deadlock each other.
+	cartQuery := `
+		SELECT
+			ci.product_id,
+			ci.quantity,
+			p.title,
+			p.price,
+			p.stock
+		FROM
+			cart_items AS ci
+		INNER JOIN
+			products AS p
+		ON
+			ci.product_id = p.id
+		WHERE
+			ci.user_id = ?
+		ORDER BY p.id
+	` + m.Dialect.ForUpdate()
+
+	rows, err := tx.QueryContext(ctx, cartQuery, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - tx.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	now := time.Now()
+	order := &Order{
+		UserID:     userID,
+		Status:     OrderStatusPending,
+		Currency:   money.DefaultCurrency,
+		TotalPrice: money.Zero(money.DefaultCurrency),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Items:      []*OrderItem{},
+	}
+
+	for rows.Next() {
+		item := &OrderItem{CreatedAt: now}
+
+		var stock int
+
+		err := rows.Scan(
+			&item.ProductID,
+			&item.Quantity,
+			&item.ProductTitle,
+			&item.UnitPrice,
+			&stock,
+		)
+		if err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		if item.Quantity > stock {
+			rows.Close()
+			return nil, &InsufficientStockError{ProductID: item.ProductID, Title: item.ProductTitle, Requested: item.Quantity, Available: stock}
+		}
+
+		item.TotalPrice = item.UnitPrice.Multiply(item.Quantity)
+
+		order.TotalPrice = order.TotalPrice.Add(item.TotalPrice)
+		order.TotalQuantity += item.Quantity
+		order.Items = append(order.Items, item)
+	}
+
+	// Close the rows before running further statements on the same transaction.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	if len(order.Items) == 0 {
+		slog.InfoContext(ctx, "OrderModel.CreateOrderFromCart - no cart items found")
+		return nil, ErrNoCartItemsFound
+	}
+
+	// 2.
+	insertOrderQuery := `
+		INSERT INTO orders(
+			user_id, status, currency, total_price, total_quantity, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := tx.ExecContext(ctx, insertOrderQuery, order.UserID, order.Status, order.Currency, order.TotalPrice, order.TotalQuantity, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Create Order - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	orderID, err := result.LastInsertId()
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - result.LastInsertId", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	order.ID = int(orderID)
+
+	// 3.
+	insertItemQuery := `
+		INSERT INTO order_items(
+			order_id, product_id, product_title, unit_price, quantity, total_price, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	updateStockQuery := `
+		UPDATE products
+		SET
+			stock = products.stock - ?
+		WHERE
+			products.id = ?
+	`
+	for _, item := range order.Items {
+		item.OrderID = order.ID
+
+		result, err := tx.ExecContext(ctx, insertItemQuery, item.OrderID, item.ProductID, item.ProductTitle, item.UnitPrice, item.Quantity, item.TotalPrice, item.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Create Order Item - tx.Exec", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Create Order Item - result.LastInsertId", "error", err)
+			return nil, queryError(ctx, err)
+		}
+		item.ID = int(itemID)
+
+		_, err = tx.ExecContext(ctx, updateStockQuery, item.Quantity, item.ProductID)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Update Stock - tx.Exec", "error", err)
+			return nil, queryError(ctx, err)
+		}
+	}
+
+	// 4.
+	err = insertOrderStatusChange(ctx, tx, order.ID, nil, order.Status, &userID, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Insert History - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// 5.
+	_, err = tx.ExecContext(ctx, `DELETE FROM cart_items WHERE user_id = ?`, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - Clear Cart - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.CreateOrderFromCart - tx.Commit failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return order, nil
+}
+
+// GetOrdersByUser returns one page of the user's orders, newest first, together
+// with the total number of orders the user has placed.
+func (m *OrderModel) GetOrdersByUser(ctx context.Context, userID, limit, offset int) ([]*Order, int, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// 1. Count every order so the caller can paginate
+	var total int
+
+	countQuery := `SELECT COUNT(*) FROM orders WHERE user_id = ?`
+
+	err := m.DB.QueryRowContext(ctx, countQuery, userID).Scan(&total)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrdersByUser - m.DB.QueryRow", "error", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	// 2. Load the requested page
+	query := `
+		SELECT
+			o.id,
+			o.user_id,
+			o.status,
+			o.currency,
+			o.total_price,
+			o.total_quantity,
+			o.created_at,
+			o.updated_at
+		FROM
+			orders AS o
+		WHERE
+			o.user_id = ?
+		ORDER BY o.id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrdersByUser - m.DB.Query", "error", err)
+		return nil, 0, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	orders := make([]*Order, 0)
+
+	for rows.Next() {
+		order := &Order{Items: []*OrderItem{}}
+
+		err := rows.Scan(
+			&order.ID,
+			&order.UserID,
+			&order.Status,
+			&order.Currency,
+			&order.TotalPrice,
+			&order.TotalQuantity,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.GetOrdersByUser - rows.Scan", "error", err)
+			return nil, 0, queryError(ctx, err)
+		}
+
+		order.TotalPrice.Currency = order.Currency
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrdersByUser - rows.Err", "error", err)
+		return nil, 0, queryError(ctx, err)
+	}
+
+	return orders, total, nil
+}
+
+// GetOrderByID returns a single order with its items. The order must belong to
+// the given user, otherwise ErrOrderNotFound is returned.
+func (m *OrderModel) GetOrderByID(ctx context.Context, userID, orderID int) (*Order, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// 1. Query
+	query := `
+		SELECT
+			o.id,
+			o.user_id,
+			o.status,
+			o.currency,
+			o.total_price,
+			o.total_quantity,
+			o.created_at,
+			o.updated_at,
+
+			oi.id,
+			oi.order_id,
+			oi.product_id,
+			oi.product_title,
+			oi.unit_price,
+			oi.quantity,
+			oi.total_price,
+			oi.created_at
+		FROM
+			orders AS o
+		INNER JOIN
+			order_items AS oi
+		ON
+			oi.order_id = o.id
+		WHERE
+			o.id = ? AND o.user_id = ?
+		ORDER BY
+			oi.id
+	`
+
+	// 2. Run the query
+	rows, err := m.DB.QueryContext(ctx, query, orderID, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrderByID - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	// 3. Build the order from the first row and collect every item
+	var order *Order
+
+	for rows.Next() {
+		o := Order{}
+		item := &OrderItem{}
+
+		err := rows.Scan(
+			&o.ID,
+			&o.UserID,
+			&o.Status,
+			&o.Currency,
+			&o.TotalPrice,
+			&o.TotalQuantity,
+			&o.CreatedAt,
+			&o.UpdatedAt,
+
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.ProductTitle,
+			&item.UnitPrice,
+			&item.Quantity,
+			&item.TotalPrice,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.GetOrderByID - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		// Every supported currency has two decimal places, so the scanned
+		// amounts only need the order's currency code attached
+		o.TotalPrice.Currency = o.Currency
+		item.UnitPrice.Currency = o.Currency
+		item.TotalPrice.Currency = o.Currency
+
+		if order == nil {
+			o.Items = []*OrderItem{}
+			order = &o
+		}
+		order.Items = append(order.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrderByID - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	return order, nil
+}
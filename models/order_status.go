@@ -0,0 +1,391 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusPreparing OrderStatus = "preparing"
+	OrderStatusReady     OrderStatus = "ready"
+	OrderStatusCompleted OrderStatus = "completed"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// orderStatusTransitions lists, for every status, the statuses an order may move to next.
+// Orders move pending -> confirmed -> preparing -> ready -> completed, and may leave
+// the happy path by being cancelled before they are ready or refunded once paid for.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusPreparing, OrderStatusCancelled},
+	OrderStatusPreparing: {OrderStatusReady, OrderStatusCancelled},
+	OrderStatusReady:     {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// customerCancellableStatuses are the statuses in which customers may still
+// cancel their own order. Once the kitchen is preparing it, only staff can.
+var customerCancellableStatuses = []OrderStatus{OrderStatusPending, OrderStatusConfirmed}
+
+// IsValid reports whether s is one of the known order statuses.
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomerCanCancel reports whether a customer may cancel their own order in status s.
+func (s OrderStatus) CustomerCanCancel() bool {
+	return slices.Contains(customerCancellableStatuses, s)
+}
+
+// CustomerCanTransitionTo reports whether a customer may move their own order
+// from status s to next. Cancelling early is the only move customers make.
+func (s OrderStatus) CustomerCanTransitionTo(next OrderStatus) bool {
+	return next == OrderStatusCancelled && s.CustomerCanCancel()
+}
+
+// NextStatuses returns the statuses an order in status s may move to.
+func (s OrderStatus) NextStatuses() []OrderStatus {
+	return orderStatusTransitions[s]
+}
+
+// InvalidStatusTransitionError is returned when an order is asked to move to a
+// status that is not reachable from its current one.
+// It matches ErrInvalidStatusTransition with errors.Is.
+type InvalidStatusTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("order cannot move from %s to %s", e.From, e.To)
+}
+
+func (e *InvalidStatusTransitionError) Is(target error) bool {
+	return target == ErrInvalidStatusTransition
+}
+
+// OrderStatusChange is a single row of an order's status history.
+type OrderStatusChange struct {
+	ID         int          `json:"id"`
+	OrderID    int          `json:"orderId"`
+	FromStatus *OrderStatus `json:"fromStatus"`
+	ToStatus   OrderStatus  `json:"toStatus"`
+	ChangedBy  *int         `json:"changedBy"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+// insertOrderStatusChange records a status change inside an existing transaction.
+func insertOrderStatusChange(ctx context.Context, tx *sql.Tx, orderID int, from *OrderStatus, to OrderStatus, changedBy *int, at time.Time) error {
+	insertQuery := `
+		INSERT INTO order_status_history(
+			order_id, from_status, to_status, changed_by, created_at
+		) VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := tx.ExecContext(ctx, insertQuery, orderID, from, to, changedBy, at)
+	return queryError(ctx, err)
+}
+
+// UpdateOrderStatus moves an order to a new status and records the change in
+// order_status_history. The order row is locked for the duration of the
+// transaction so that concurrent updates cannot both pass the transition check.
+// When ownerID is set the change is made by that customer: the order must be
+// theirs, otherwise ErrOrderNotFound is returned, and they may only cancel it
+// while it is pending or confirmed.
+// Cancelling an order puts its items back in stock.
+func (m *OrderModel) UpdateOrderStatus(ctx context.Context, orderID int, ownerID *int, to OrderStatus, changedBy *int) (*Order, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - m.DB.Begin failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: Lock the order and read its current status
+	// Step 2: Check the transition
+	// Step 3: Update the order and record the history row
+	// Step 4: Return the items of a cancelled order to stock
+
+	// 1.
+	var (
+		current OrderStatus
+		userID  int
+	)
+
+	selectQuery := `SELECT status, user_id FROM orders WHERE id = ? ` + m.Dialect.ForUpdate()
+
+	err = tx.QueryRowContext(ctx, selectQuery, orderID).Scan(&current, &userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	} else if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - row.Scan", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	if ownerID != nil && *ownerID != userID {
+		return nil, ErrOrderNotFound
+	}
+
+	// 2.
+	allowed := current.CanTransitionTo(to)
+	if ownerID != nil {
+		allowed = current.CustomerCanTransitionTo(to)
+	}
+	if !allowed {
+		return nil, &InvalidStatusTransitionError{From: current, To: to}
+	}
+
+	// 3.
+	now := time.Now()
+
+	updateQuery := `
+		UPDATE orders
+		SET
+			status = ?,
+			updated_at = ?
+		WHERE
+			orders.id = ?
+	`
+	_, err = tx.ExecContext(ctx, updateQuery, to, now, orderID)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - Update Order - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	err = insertOrderStatusChange(ctx, tx, orderID, &current, to, changedBy, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - Insert History - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// 4.
+	if to == OrderStatusCancelled {
+		restockQuery := `
+			UPDATE products
+			SET
+				stock = stock + (
+					SELECT SUM(oi.quantity)
+					FROM order_items AS oi
+					WHERE oi.order_id = ? AND oi.product_id = products.id
+				)
+			WHERE
+				id IN (SELECT product_id FROM order_items WHERE order_id = ?)
+		`
+		_, err = tx.ExecContext(ctx, restockQuery, orderID, orderID)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - Restock - tx.Exec", "error", err)
+			return nil, queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.UpdateOrderStatus - tx.Commit failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return &Order{ID: orderID, Status: to, UpdatedAt: now}, nil
+}
+
+// GetOrderStatusHistory returns every recorded status change of an order, oldest first.
+func (m *OrderModel) GetOrderStatusHistory(ctx context.Context, orderID int) ([]*OrderStatusChange, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			h.id,
+			h.order_id,
+			h.from_status,
+			h.to_status,
+			h.changed_by,
+			h.created_at
+		FROM
+			order_status_history AS h
+		WHERE
+			h.order_id = ?
+		ORDER BY h.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, orderID)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrderStatusHistory - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	history := make([]*OrderStatusChange, 0)
+
+	for rows.Next() {
+		var (
+			change     OrderStatusChange
+			fromStatus sql.NullString
+			changedBy  sql.NullInt64
+		)
+
+		err := rows.Scan(
+			&change.ID,
+			&change.OrderID,
+			&fromStatus,
+			&change.ToStatus,
+			&changedBy,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.GetOrderStatusHistory - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		if fromStatus.Valid {
+			status := OrderStatus(fromStatus.String)
+			change.FromStatus = &status
+		}
+		if changedBy.Valid {
+			userID := int(changedBy.Int64)
+			change.ChangedBy = &userID
+		}
+
+		history = append(history, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrderStatusHistory - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return history, nil
+}
+
+// GetOrdersByStatus returns every order, across all users, that is in one of the
+// given statuses, oldest first and with its items. It backs the kitchen queue.
+func (m *OrderModel) GetOrdersByStatus(ctx context.Context, statuses []OrderStatus) ([]*Order, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	if len(statuses) == 0 {
+		return []*Order{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = status
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			o.id,
+			o.user_id,
+			o.status,
+			o.currency,
+			o.total_price,
+			o.total_quantity,
+			o.created_at,
+			o.updated_at,
+
+			oi.id,
+			oi.order_id,
+			oi.product_id,
+			oi.product_title,
+			oi.unit_price,
+			oi.quantity,
+			oi.total_price,
+			oi.created_at
+		FROM
+			orders AS o
+		INNER JOIN
+			order_items AS oi
+		ON
+			oi.order_id = o.id
+		WHERE
+			o.status IN (%s)
+		ORDER BY
+			o.id, oi.id
+	`, placeholders)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrdersByStatus - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	orders := make([]*Order, 0)
+	ordersMap := make(map[int]*Order)
+
+	for rows.Next() {
+		o := Order{}
+		item := &OrderItem{}
+
+		err := rows.Scan(
+			&o.ID,
+			&o.UserID,
+			&o.Status,
+			&o.Currency,
+			&o.TotalPrice,
+			&o.TotalQuantity,
+			&o.CreatedAt,
+			&o.UpdatedAt,
+
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.ProductTitle,
+			&item.UnitPrice,
+			&item.Quantity,
+			&item.TotalPrice,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "OrderModel.GetOrdersByStatus - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		// Every supported currency has two decimal places, so the scanned
+		// amounts only need the order's currency code attached
+		o.TotalPrice.Currency = o.Currency
+		item.UnitPrice.Currency = o.Currency
+		item.TotalPrice.Currency = o.Currency
+
+		order, ok := ordersMap[o.ID]
+		if !ok {
+			o.Items = []*OrderItem{}
+			order = &o
+			ordersMap[o.ID] = order
+			orders = append(orders, order)
+		}
+		order.Items = append(order.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "OrderModel.GetOrdersByStatus - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,237 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+)
+
+var allStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusPreparing,
+	models.OrderStatusReady,
+	models.OrderStatusCompleted,
+	models.OrderStatusCancelled,
+	models.OrderStatusRefunded,
+}
+
+func TestOrderStatusTransitions(t *testing.T) {
+	// Every allowed move; any pair not listed must be refused
+	allowed := map[models.OrderStatus][]models.OrderStatus{
+		models.OrderStatusPending:   {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+		models.OrderStatusConfirmed: {models.OrderStatusPreparing, models.OrderStatusCancelled},
+		models.OrderStatusPreparing: {models.OrderStatusReady, models.OrderStatusCancelled},
+		models.OrderStatusReady:     {models.OrderStatusCompleted, models.OrderStatusRefunded},
+		models.OrderStatusCompleted: {models.OrderStatusRefunded},
+	}
+
+	for _, from := range allStatuses {
+		if !from.IsValid() {
+			t.Errorf("%s.IsValid() = false", from)
+		}
+		for _, to := range allStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s.CanTransitionTo(%s) = %t, want %t", from, to, got, want)
+			}
+		}
+	}
+
+	for _, status := range allStatuses {
+		want := status == models.OrderStatusPending || status == models.OrderStatusConfirmed
+		if got := status.CustomerCanCancel(); got != want {
+			t.Errorf("%s.CustomerCanCancel() = %t, want %t", status, got, want)
+		}
+		if got := status.CustomerCanTransitionTo(models.OrderStatusCancelled); got != want {
+			t.Errorf("%s.CustomerCanTransitionTo(cancelled) = %t, want %t", status, got, want)
+		}
+		for _, to := range allStatuses {
+			if to != models.OrderStatusCancelled && status.CustomerCanTransitionTo(to) {
+				t.Errorf("%s.CustomerCanTransitionTo(%s) = true, customers may only cancel", status, to)
+			}
+		}
+	}
+
+	if models.OrderStatus("shipped").IsValid() {
+		t.Error(`OrderStatus("shipped").IsValid() = true`)
+	}
+	if models.OrderStatus("shipped").CanTransitionTo(models.OrderStatusConfirmed) {
+		t.Error("an unknown status can move to confirmed")
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	const stock, quantity = 5, 2
+
+	tests := []struct {
+		name      string
+		path      []models.OrderStatus // applied in order from pending
+		wantErr   error                // of the last step
+		wantStock int
+	}{
+		{
+			name:      "happy path",
+			path:      []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPreparing, models.OrderStatusReady, models.OrderStatusCompleted},
+			wantStock: stock - quantity,
+		},
+		{
+			name:      "cancel restocks",
+			path:      []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusCancelled},
+			wantStock: stock,
+		},
+		{
+			name:      "skip a step",
+			path:      []models.OrderStatus{models.OrderStatusReady},
+			wantErr:   models.ErrInvalidStatusTransition,
+			wantStock: stock - quantity,
+		},
+		{
+			name:      "cancel when ready",
+			path:      []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPreparing, models.OrderStatusReady, models.OrderStatusCancelled},
+			wantErr:   models.ErrInvalidStatusTransition,
+			wantStock: stock - quantity,
+		},
+		{
+			name:      "leave a final status",
+			path:      []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusPending},
+			wantErr:   models.ErrInvalidStatusTransition,
+			wantStock: stock,
+		},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := s.open(t)
+
+				userID := createUser(t, repo, "bob@example.com")
+				product, err := repo.CreateProduct(ctx, &models.ProductInput{
+					Title:    "Waffle",
+					Category: "Waffle",
+					Price:    money.MustParse("6.50", money.DefaultCurrency),
+					Stock:    stock,
+				})
+				if err != nil {
+					t.Fatalf("CreateProduct: %v", err)
+				}
+				if err := repo.AddCartItem(ctx, userID, product.ID, quantity); err != nil {
+					t.Fatalf("AddCartItem: %v", err)
+				}
+				order, err := repo.CreateOrderFromCart(ctx, userID)
+				if err != nil {
+					t.Fatalf("CreateOrderFromCart: %v", err)
+				}
+
+				for i, to := range tt.path {
+					_, err = repo.UpdateOrderStatus(ctx, order.ID, nil, to, &userID)
+					if i < len(tt.path)-1 && err != nil {
+						t.Fatalf("UpdateOrderStatus(%s): %v", to, err)
+					}
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("last UpdateOrderStatus() error = %v, want %v", err, tt.wantErr)
+				}
+
+				history, err := repo.GetOrderStatusHistory(ctx, order.ID)
+				if err != nil {
+					t.Fatalf("GetOrderStatusHistory: %v", err)
+				}
+				wantChanges := len(tt.path) + 1 // the order was created pending
+				if tt.wantErr != nil {
+					wantChanges--
+				}
+				if len(history) != wantChanges {
+					t.Errorf("history has %d changes, want %d", len(history), wantChanges)
+				}
+
+				product, err = repo.GetProductByID(ctx, product.ID)
+				if err != nil {
+					t.Fatalf("GetProductByID: %v", err)
+				}
+				if product.Stock != tt.wantStock {
+					t.Errorf("stock = %d, want %d", product.Stock, tt.wantStock)
+				}
+			})
+		}
+	}
+
+	for _, s := range stores {
+		t.Run(s.name+"/unknown order", func(t *testing.T) {
+			_, err := s.open(t).UpdateOrderStatus(context.Background(), 42, nil, models.OrderStatusConfirmed, nil)
+			if !errors.Is(err, models.ErrOrderNotFound) {
+				t.Errorf("UpdateOrderStatus() error = %v, want %v", err, models.ErrOrderNotFound)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatusByCustomer(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []models.OrderStatus // applied by staff from pending first
+		to      models.OrderStatus
+		other   bool // the order belongs to another user
+		wantErr error
+	}{
+		{name: "cancel pending", to: models.OrderStatusCancelled},
+		{name: "cancel confirmed", path: []models.OrderStatus{models.OrderStatusConfirmed}, to: models.OrderStatusCancelled},
+		{
+			name:    "cancel preparing",
+			path:    []models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusPreparing},
+			to:      models.OrderStatusCancelled,
+			wantErr: models.ErrInvalidStatusTransition,
+		},
+		{name: "confirm own order", to: models.OrderStatusConfirmed, wantErr: models.ErrInvalidStatusTransition},
+		{name: "cancel another user's order", to: models.OrderStatusCancelled, other: true, wantErr: models.ErrOrderNotFound},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := s.open(t)
+
+				ownerID := createUser(t, repo, "bob@example.com")
+				customerID := ownerID
+				if tt.other {
+					customerID = createUser(t, repo, "eve@example.com")
+				}
+
+				product, err := repo.CreateProduct(ctx, &models.ProductInput{
+					Title:    "Waffle",
+					Category: "Waffle",
+					Price:    money.MustParse("6.50", money.DefaultCurrency),
+					Stock:    5,
+				})
+				if err != nil {
+					t.Fatalf("CreateProduct: %v", err)
+				}
+				if err := repo.AddCartItem(ctx, ownerID, product.ID, 1); err != nil {
+					t.Fatalf("AddCartItem: %v", err)
+				}
+				order, err := repo.CreateOrderFromCart(ctx, ownerID)
+				if err != nil {
+					t.Fatalf("CreateOrderFromCart: %v", err)
+				}
+				for _, to := range tt.path {
+					if _, err := repo.UpdateOrderStatus(ctx, order.ID, nil, to, nil); err != nil {
+						t.Fatalf("UpdateOrderStatus(%s) by staff: %v", to, err)
+					}
+				}
+
+				_, err = repo.UpdateOrderStatus(ctx, order.ID, &customerID, tt.to, &customerID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UpdateOrderStatus(%s) by customer error = %v, want %v", tt.to, err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
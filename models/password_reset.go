@@ -0,0 +1,138 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordReset is a link sent to a user who forgot their password. Only the
+// SHA-256 hash of its token is stored, and the token works once.
+type PasswordReset struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type PasswordResetModel struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+func (m *PasswordResetModel) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	stmt := `
+		INSERT INTO password_resets (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err := m.DB.ExecContext(ctx, stmt, userID, tokenHash, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.CreatePasswordReset - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+func (m *PasswordResetModel) LastPasswordResetSentAt(ctx context.Context, userID int) (time.Time, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	var sentAt time.Time
+	query := `SELECT created_at FROM password_resets WHERE user_id = ? ORDER BY created_at DESC LIMIT 1`
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&sentAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		slog.ErrorContext(ctx, "PasswordResetModel.LastPasswordResetSentAt - m.DB.QueryRow", "error", err)
+		return time.Time{}, queryError(ctx, err)
+	}
+
+	return sentAt, nil
+}
+
+func (m *PasswordResetModel) ResetPassword(ctx context.Context, tokenHash, password string) (int, error) {
+	// 1. Hash the new password, before the transaction as it takes a while
+	// 2. Mark the token as used, if it is known, unused and unexpired
+	// 3. Replace the password of its user
+	// 4. Mark the user's other reset links as used, so none works anymore
+
+	// 1.
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - bcrypt.GeneratePassword", "error", err)
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - m.DB.Begin", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	defer tx.Rollback()
+
+	// 2.
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE password_resets
+		SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, now, tokenHash, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - tx.Exec use token", "error", err)
+		return 0, queryError(ctx, err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - result.RowsAffected", "error", err)
+		return 0, queryError(ctx, err)
+	}
+	if used == 0 {
+		return 0, ErrInvalidPasswordReset
+	}
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM password_resets WHERE token_hash = ?`, tokenHash).Scan(&userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - tx.QueryRow", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	// 3.
+	_, err = tx.ExecContext(ctx, `UPDATE users SET hash = ?, updated_at = ? WHERE id = ?`, hashPassword, now, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - tx.Exec update user", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	// 4.
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	`, now, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - tx.Exec use other tokens", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "PasswordResetModel.ResetPassword - tx.Commit", "error", err)
+		return 0, queryError(ctx, err)
+	}
+
+	return userID, nil
+}
@@ -1,24 +1,34 @@
 package models
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"sort"
 	"time"
 
+	money "dessert-ordering-go-system/internal/money"
 	utils "dessert-ordering-go-system/internal/utils"
 )
 
 type Product struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Category    string    `json:"category"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Thumbnail   string    `json:"thumbnail"`
-	Images      []string  `json:"images"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Category    string      `json:"category"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Thumbnail   string      `json:"thumbnail"`
+	Images      []string    `json:"images"`
+	Stock       int         `json:"stock"`
+	ArchivedAt  *time.Time  `json:"archivedAt,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// IsArchived reports whether the product has been soft-archived. Archived products
+// are hidden from the catalog but still resolve for existing cart items and orders.
+func (p *Product) IsArchived() bool {
+	return p.ArchivedAt != nil
 }
 
 // ProductForDisplay is a variant of the Product struct designed for frontend display,
@@ -31,6 +41,7 @@ type ProductForDisplay struct {
 	Price       string    `json:"price"` // Changed to string type
 	Thumbnail   string    `json:"thumbnail"`
 	Images      []string  `json:"images"`
+	Stock       int       `json:"stock"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -44,15 +55,33 @@ func NewProductForDisplay(p *Product) *ProductForDisplay {
 		Title:       p.Title,
 		Category:    p.Category,
 		Description: p.Description,
-		// Format the float64 price to a string with 2 decimal places
+		// Format the exact price to a string with the currency's decimal places
 		Price:     utils.FormatPrice(p.Price),
 		Thumbnail: p.Thumbnail,
 		Images:    p.Images,
+		Stock:     p.Stock,
 		CreatedAt: p.CreatedAt,
 		UpdatedAt: p.UpdatedAt,
 	}
 }
 
+// NewCartItemProductForDisplay converts the product joined to a cart item into a
+// ProductForDisplay. It carries no images or timestamps.
+func NewCartItemProductForDisplay(p *CartItemProduct) *ProductForDisplay {
+	if p == nil {
+		return nil
+	}
+	return &ProductForDisplay{
+		ID:          p.ID,
+		Title:       p.Title,
+		Category:    p.Category,
+		Description: p.Description,
+		Price:       utils.FormatPrice(p.Price),
+		Thumbnail:   p.Thumbnail,
+		Stock:       p.Stock,
+	}
+}
+
 // NewProductsForDisplay converts a slice of models.Product pointers
 // to a slice of ProductForDisplay pointers.
 func NewProductsForDisplay(products []*Product) []*ProductForDisplay {
@@ -71,18 +100,36 @@ type ProductImage struct {
 	ID        int       `json:"id"`
 	ProductID int       `json:"productId"`
 	Image     string    `json:"image"`
+	Position  int       `json:"position"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
 type ProductModel struct {
-	DB *sql.DB
+	DB           *sql.DB
+	Dialect      Dialect
+	QueryTimeout time.Duration
 }
 
 type ProductImageModel struct {
-	DB *sql.DB
+	DB           *sql.DB
+	Dialect      Dialect
+	QueryTimeout time.Duration
 }
 
-func (m *ProductModel) GetAllProducts() ([]*Product, error) {
+// GetAllProducts returns the catalog, leaving out archived products.
+func (m *ProductModel) GetAllProducts(ctx context.Context) ([]*Product, error) {
+	return m.getProducts(ctx, false)
+}
+
+// GetAllProductsIncludingArchived returns every product, archived or not.
+func (m *ProductModel) GetAllProductsIncludingArchived(ctx context.Context) ([]*Product, error) {
+	return m.getProducts(ctx, true)
+}
+
+func (m *ProductModel) getProducts(ctx context.Context, includeArchived bool) ([]*Product, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1. Construct the SQL query.
 	query := `
 					SELECT
@@ -92,6 +139,8 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 						p.description,
 						p.price,
 						p.thumbnail,
+						p.stock,
+						p.archived_at,
 						p.created_at,
 						p.updated_at,
 						pi.image
@@ -101,16 +150,18 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 						product_images AS pi 
 					ON 
 						p.id = pi.product_id
+					WHERE
+						? OR p.archived_at IS NULL
 					ORDER BY
-						p.id, pi.id
+						p.id, pi.position, pi.id
 				
 	`
 
 	// 2. Execute the query. db.Query() returns a *sql.Rows (multiple rows)
-	rows, err := m.DB.Query(query)
+	rows, err := m.DB.QueryContext(ctx, query, includeArchived)
 	if err != nil {
-		log.Printf("ERROR: ProductModel.GetAllProducts - m.DB.Query: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "ProductModel.GetAllProducts - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
 	}
 	defer rows.Close() // Ensure rows are closed after we're done
 
@@ -123,8 +174,10 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 			title       string
 			category    string
 			description string
-			price       float64
+			price       money.Money
 			thumbnail   string
+			stock       int
+			archivedAt  sql.NullTime
 			createdAt   time.Time
 			updatedAt   time.Time
 			imageURL    sql.NullString // Use sql.NullString to handle NULL image_url values from LEFT JOIN
@@ -137,13 +190,15 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 			&description,
 			&price,
 			&thumbnail,
+			&stock,
+			&archivedAt,
 			&createdAt,
 			&updatedAt,
 			&imageURL, // Scan into sql.NullString
 		)
 		if err != nil {
-			log.Printf("ERROR: ProductModel.GetAllProducts - rows.Scan: %v", err)
-			return nil, err
+			slog.ErrorContext(ctx, "ProductModel.GetAllProducts - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
 		}
 
 		// Check if the product is already in our map (i.e., we've seen its details before)
@@ -157,10 +212,14 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 				Description: description,
 				Price:       price,
 				Thumbnail:   thumbnail,
+				Stock:       stock,
 				CreatedAt:   createdAt,
 				UpdatedAt:   updatedAt,
 				Images:      []string{},
 			}
+			if archivedAt.Valid {
+				p.ArchivedAt = &archivedAt.Time
+			}
 			productsMap[productID] = p
 		}
 
@@ -172,8 +231,8 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 
 	// 4. Check for errors from iterating over the rows.
 	if err := rows.Err(); err != nil {
-		log.Printf("ERROR: ProductModel.GetAllProducts - rows.Err: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "ProductModel.GetAllProducts - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
 	}
 
 	products := []*Product{}
@@ -189,7 +248,10 @@ func (m *ProductModel) GetAllProducts() ([]*Product, error) {
 	return products, nil
 }
 
-func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
+func (m *ProductModel) GetProductByID(ctx context.Context, ProductID int) (*Product, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1. Query
 	query := `
 		SELECT
@@ -199,6 +261,8 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 			p.description,
 			p.price,
 			p.thumbnail,
+			p.stock,
+			p.archived_at,
 			p.created_at,
 			p.updated_at,
 			pi.image
@@ -211,15 +275,15 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 		WHERE
 			p.id = ?
 		ORDER BY
-			pi.id
+			pi.position, pi.id
 		
 	`
 
 	// 2. Run the query
-	rows, err := m.DB.Query(query, ProductID)
+	rows, err := m.DB.QueryContext(ctx, query, ProductID)
 	if err != nil {
-		log.Printf("ERROR: ProductModel.GetProductByID - m.DB.Query: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "ProductModel.GetProductByID - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -232,8 +296,10 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 			title       string
 			category    string
 			description string
-			price       float64
+			price       money.Money
 			thumbnail   string
+			stock       int
+			archivedAt  sql.NullTime
 			createdAt   time.Time
 			updatedAt   time.Time
 			imageURL    sql.NullString
@@ -246,13 +312,15 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 			&description,
 			&price,
 			&thumbnail,
+			&stock,
+			&archivedAt,
 			&createdAt,
 			&updatedAt,
 			&imageURL,
 		)
 		if err != nil {
-			log.Printf("ERROR: ProductModel.GetProductByID - rows.Scan: %v", err)
-			return nil, err
+			slog.ErrorContext(ctx, "ProductModel.GetProductByID - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
 		}
 
 		// Check if the product has been set or not
@@ -265,9 +333,13 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 				Price:       price,
 				Thumbnail:   thumbnail,
 				Images:      []string{},
+				Stock:       stock,
 				CreatedAt:   createdAt,
 				UpdatedAt:   updatedAt,
 			}
+			if archivedAt.Valid {
+				product.ArchivedAt = &archivedAt.Time
+			}
 		}
 
 		// Check if the image is valid
@@ -278,8 +350,8 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 
 	// Check for rows error
 	if err := rows.Err(); err != nil {
-		log.Printf("ERROR: ProductModel.GetProductByID - rows.Err: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "ProductModel.GetProductByID - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
 	}
 
 	// Check no rows found
@@ -289,3 +361,238 @@ func (m *ProductModel) GetProductByID(ProductID int) (*Product, error) {
 
 	return product, nil
 }
+
+// ProductInput holds the editable fields of a product.
+type ProductInput struct {
+	Title       string
+	Category    string
+	Description string
+	Price       money.Money
+	Thumbnail   string
+	// Stock is the stock of a new product. UpdateProduct adds StockChange to
+	// the current stock instead, stopping at zero, so that it cannot undo the
+	// reservations of checkouts made while an admin edits the product.
+	Stock       int
+	StockChange int
+}
+
+func (m *ProductModel) CreateProduct(ctx context.Context, input *ProductInput) (*Product, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	insertQuery := `
+		INSERT INTO products(
+			title, category, description, price, thumbnail, stock, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := m.DB.ExecContext(ctx, insertQuery, input.Title, input.Category, input.Description, input.Price, input.Thumbnail, input.Stock, now, now)
+	if err != nil {
+		if IsDuplicateEntryError(err) {
+			return nil, ErrDuplicateRecord
+		}
+		slog.ErrorContext(ctx, "ProductModel.CreateProduct - m.DB.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	productID, err := result.LastInsertId()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.CreateProduct - result.LastInsertId", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return m.GetProductByID(ctx, int(productID))
+}
+
+func (m *ProductModel) UpdateProduct(ctx context.Context, productID int, input *ProductInput) (*Product, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	updateQuery := `
+		UPDATE products
+		SET
+			title = ?,
+			category = ?,
+			description = ?,
+			price = ?,
+			thumbnail = ?,
+			stock = ` + m.Dialect.AddClamped("stock") + `,
+			updated_at = ?
+		WHERE
+			products.id = ?
+	`
+	_, err := m.DB.ExecContext(ctx, updateQuery, input.Title, input.Category, input.Description, input.Price, input.Thumbnail, input.StockChange, time.Now(), productID)
+	if err != nil {
+		if IsDuplicateEntryError(err) {
+			return nil, ErrDuplicateRecord
+		}
+		slog.ErrorContext(ctx, "ProductModel.UpdateProduct - m.DB.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// MySQL reports 0 affected rows when nothing changed, so the reload below is
+	// what tells us whether the product exists.
+	return m.GetProductByID(ctx, productID)
+}
+
+// SetProductArchived archives or restores a product. Archiving is a soft delete:
+// the row stays so that cart items and orders referencing it keep working.
+func (m *ProductModel) SetProductArchived(ctx context.Context, productID int, archived bool) (*Product, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	var archivedAt *time.Time
+	now := time.Now()
+	if archived {
+		archivedAt = &now
+	}
+
+	updateQuery := `
+		UPDATE products
+		SET
+			archived_at = ?,
+			updated_at = ?
+		WHERE
+			products.id = ?
+	`
+	_, err := m.DB.ExecContext(ctx, updateQuery, archivedAt, now, productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.SetProductArchived - m.DB.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return m.GetProductByID(ctx, productID)
+}
+
+// UpsertProduct creates the product with the given title, or updates it when it
+// already exists, and replaces its images with the given ones in order. It is
+// used by the seed command, so running it again with the same input changes nothing.
+func (m *ProductModel) UpsertProduct(ctx context.Context, input *ProductInput, images []string) (*Product, bool, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.UpsertProduct - m.DB.Begin failed", "error", err)
+		return nil, false, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: Look up the product by its title
+	// Step 2: Insert or update the product
+	// Step 3: Replace the product images
+
+	// 1.
+	var productID int64
+	created := false
+	now := time.Now()
+
+	err = tx.QueryRowContext(ctx, `SELECT id FROM products WHERE title = ? `+m.Dialect.ForUpdate(), input.Title).Scan(&productID)
+	if err != nil && err != sql.ErrNoRows {
+		slog.ErrorContext(ctx, "ProductModel.UpsertProduct - row.Scan", "error", err)
+		return nil, false, queryError(ctx, err)
+	}
+
+	// 2.
+	if err == sql.ErrNoRows {
+		insertQuery := `
+			INSERT INTO products(
+				title, category, description, price, thumbnail, stock, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`
+		result, err := tx.ExecContext(ctx, insertQuery, input.Title, input.Category, input.Description, input.Price, input.Thumbnail, input.Stock, now, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductModel.UpsertProduct - Create Product - tx.Exec", "error", err)
+			return nil, false, queryError(ctx, err)
+		}
+
+		productID, err = result.LastInsertId()
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductModel.UpsertProduct - result.LastInsertId", "error", err)
+			return nil, false, queryError(ctx, err)
+		}
+		created = true
+	} else {
+		updateQuery := `
+			UPDATE products
+			SET
+				category = ?,
+				description = ?,
+				price = ?,
+				thumbnail = ?,
+				stock = ?,
+				updated_at = ?
+			WHERE
+				products.id = ?
+		`
+		_, err := tx.ExecContext(ctx, updateQuery, input.Category, input.Description, input.Price, input.Thumbnail, input.Stock, now, productID)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductModel.UpsertProduct - Update Product - tx.Exec", "error", err)
+			return nil, false, queryError(ctx, err)
+		}
+	}
+
+	// 3.
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_images WHERE product_id = ?`, productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.UpsertProduct - Delete Images - tx.Exec", "error", err)
+		return nil, false, queryError(ctx, err)
+	}
+
+	insertImageQuery := `
+		INSERT INTO product_images(
+			product_id, image, position, created_at
+		) VALUES (?, ?, ?, ?)
+	`
+	for position, image := range images {
+		_, err := tx.ExecContext(ctx, insertImageQuery, productID, image, position, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductModel.UpsertProduct - Create Image - tx.Exec", "error", err)
+			return nil, false, queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.UpsertProduct - tx.Commit failed", "error", err)
+		return nil, false, queryError(ctx, err)
+	}
+
+	product, err := m.GetProductByID(ctx, int(productID))
+	return product, created, queryError(ctx, err)
+}
+
+// ResetCatalog deletes every product together with everything that refers to
+// one: images, cart items and orders. It is meant for development databases only.
+func (m *ProductModel) ResetCatalog(ctx context.Context) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.ResetCatalog - m.DB.Begin failed", "error", err)
+		return queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Children first so that no foreign key is left dangling
+	tables := []string{"order_status_history", "order_items", "orders", "cart_items", "product_images", "products"}
+	for _, table := range tables {
+		_, err := tx.ExecContext(ctx, "DELETE FROM "+table)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductModel.ResetCatalog - Delete - tx.Exec", "table", table, "error", err)
+			return queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductModel.ResetCatalog - tx.Commit failed", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
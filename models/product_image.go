@@ -0,0 +1,284 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// GetProductImages returns the images of a product in display order.
+func (m *ProductImageModel) GetProductImages(ctx context.Context, productID int) ([]*ProductImage, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			pi.id,
+			pi.product_id,
+			pi.image,
+			pi.position,
+			pi.created_at
+		FROM
+			product_images AS pi
+		WHERE
+			pi.product_id = ?
+		ORDER BY
+			pi.position, pi.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.GetProductImages - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	images := make([]*ProductImage, 0)
+
+	for rows.Next() {
+		image := &ProductImage{}
+
+		err := rows.Scan(
+			&image.ID,
+			&image.ProductID,
+			&image.Image,
+			&image.Position,
+			&image.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductImageModel.GetProductImages - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+		images = append(images, image)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.GetProductImages - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return images, nil
+}
+
+// GetAllProductImages returns the images of every product, grouped by product ID
+// and in display order.
+func (m *ProductImageModel) GetAllProductImages(ctx context.Context) (map[int][]*ProductImage, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			pi.id,
+			pi.product_id,
+			pi.image,
+			pi.position,
+			pi.created_at
+		FROM
+			product_images AS pi
+		ORDER BY
+			pi.product_id, pi.position, pi.id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.GetAllProductImages - m.DB.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	images := make(map[int][]*ProductImage)
+
+	for rows.Next() {
+		image := &ProductImage{}
+
+		err := rows.Scan(
+			&image.ID,
+			&image.ProductID,
+			&image.Image,
+			&image.Position,
+			&image.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductImageModel.GetAllProductImages - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+		images[image.ProductID] = append(images[image.ProductID], image)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.GetAllProductImages - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return images, nil
+}
+
+// AddProductImage attaches an image to a product, placing it after the existing ones.
+func (m *ProductImageModel) AddProductImage(ctx context.Context, productID int, image string) (*ProductImage, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - m.DB.Begin failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: Lock the product row so concurrent attaches get distinct positions
+	var pID int
+
+	err = tx.QueryRowContext(ctx, `SELECT p.id FROM products AS p WHERE p.id = ? `+m.Dialect.ForUpdate(), productID).Scan(&pID)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - productRow.Scan", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// Step 2: Find the next position
+	var position int
+
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(position), 0) + 1 FROM product_images WHERE product_id = ?`, productID).Scan(&position)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - positionRow.Scan", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// Step 3: Insert the image
+	productImage := &ProductImage{
+		ProductID: productID,
+		Image:     image,
+		Position:  position,
+		CreatedAt: time.Now(),
+	}
+
+	insertQuery := `
+		INSERT INTO product_images(
+			product_id, image, position, created_at
+		) VALUES (?, ?, ?, ?)
+	`
+	result, err := tx.ExecContext(ctx, insertQuery, productImage.ProductID, productImage.Image, productImage.Position, productImage.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - tx.Exec", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	imageID, err := result.LastInsertId()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - result.LastInsertId", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	productImage.ID = int(imageID)
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.AddProductImage - tx.Commit failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return productImage, nil
+}
+
+// ReorderProductImages sets the display order of a product's images. imageIDs
+// must contain every image of the product exactly once, first image first.
+func (m *ProductImageModel) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) ([]*ProductImage, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// Step 0: Start a database transaction for atomicity
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - m.DB.Begin failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	// Step 1: Lock the current images of the product
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM product_images WHERE product_id = ? `+m.Dialect.ForUpdate(), productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - tx.Query", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	existing := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - rows.Scan", "error", err)
+			return nil, queryError(ctx, err)
+		}
+		existing[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - rows.Err", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	// Step 2: The new order must be a permutation of the existing images
+	if len(imageIDs) != len(existing) {
+		return nil, ErrInvalidImageOrder
+	}
+	seen := make(map[int]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		if !existing[id] || seen[id] {
+			return nil, ErrInvalidImageOrder
+		}
+		seen[id] = true
+	}
+
+	// Step 3: Write the new positions
+	updateQuery := `
+		UPDATE product_images
+		SET
+			position = ?
+		WHERE
+			product_images.id = ? AND product_images.product_id = ?
+	`
+	for index, id := range imageIDs {
+		_, err := tx.ExecContext(ctx, updateQuery, index+1, id, productID)
+		if err != nil {
+			slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - tx.Exec", "error", err)
+			return nil, queryError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.ReorderProductImages - tx.Commit failed", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return m.GetProductImages(ctx, productID)
+}
+
+func (m *ProductImageModel) RemoveProductImage(ctx context.Context, productID, imageID int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	deleteQuery := `
+		DELETE FROM product_images
+		WHERE id = ? AND product_id = ?
+	`
+
+	result, err := m.DB.ExecContext(ctx, deleteQuery, imageID, productID)
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.RemoveProductImage - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.ErrorContext(ctx, "ProductImageModel.RemoveProductImage - result.RowsAffected", "error", err)
+		return queryError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrProductImageNotFound
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+)
+
+func TestUpdateProductStockChange(t *testing.T) {
+	const stock = 5
+
+	tests := []struct {
+		name      string
+		change    int
+		wantStock int
+	}{
+		{name: "unchanged", change: 0, wantStock: stock},
+		{name: "restock", change: 3, wantStock: stock + 3},
+		{name: "lower", change: -2, wantStock: stock - 2},
+		{name: "down to zero", change: -stock, wantStock: 0},
+		// An admin lowering stock that checkouts already took below the change
+		{name: "change bigger than stock", change: -stock - 4, wantStock: 0},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := s.open(t)
+
+				input := &models.ProductInput{
+					Title:    "Waffle",
+					Category: "Waffle",
+					Price:    money.MustParse("6.50", money.DefaultCurrency),
+					Stock:    stock,
+				}
+				product, err := repo.CreateProduct(ctx, input)
+				if err != nil {
+					t.Fatalf("CreateProduct: %v", err)
+				}
+
+				input.StockChange = tt.change
+				product, err = repo.UpdateProduct(ctx, product.ID, input)
+				if err != nil {
+					t.Fatalf("UpdateProduct: %v", err)
+				}
+				if product.Stock != tt.wantStock {
+					t.Errorf("stock = %d, want %d", product.Stock, tt.wantStock)
+				}
+			})
+		}
+	}
+}
+
+// TestAddClampedMySQL covers the MySQL stock expression, which the SQLite
+// stores above cannot run: stock is INT UNSIGNED there, so it must be cast to a
+// signed integer before a negative change is added.
+func TestAddClampedMySQL(t *testing.T) {
+	want := "GREATEST(CAST(stock AS SIGNED) + ?, 0)"
+	if got := models.DialectMySQL.AddClamped("stock"); got != want {
+		t.Errorf("AddClamped() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// RefreshToken is a long-lived token an API client trades for a new JWT. Only
+// the SHA-256 hash of the token is stored. Every rotation issues a new token in
+// the same family, so that reusing a rotated token can revoke all of them.
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"userId"`
+	FamilyID  string     `json:"familyId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type RefreshTokenModel struct {
+	DB           *sql.DB
+	QueryTimeout time.Duration
+}
+
+func (m *RefreshTokenModel) CreateRefreshToken(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	stmt := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := m.DB.ExecContext(ctx, stmt, userID, familyID, tokenHash, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.CreateRefreshToken - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+func (m *RefreshTokenModel) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (*RefreshToken, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// 1. Start a transaction, so that two clients racing with the same token
+	//    cannot both rotate it
+	// 2. Mark the token as rotated, if it can still be used
+	// 3. If it could, store its successor in the same family
+	// 4. If it could not because it was rotated before, revoke the family
+
+	// 1.
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - m.DB.Begin", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	defer tx.Rollback()
+
+	// 2.
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET rotated_at = ?
+		WHERE token_hash = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?
+	`, now, tokenHash, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.Exec update", "error", err)
+		return nil, queryError(ctx, err)
+	}
+	rotated, err := result.RowsAffected()
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - result.RowsAffected", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	token := &RefreshToken{}
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RotatedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.QueryRow", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	if rotated == 0 {
+		if token.RotatedAt == nil || token.RevokedAt != nil {
+			// Expired or revoked: refused, but not a sign of theft
+			return nil, ErrInvalidRefreshToken
+		}
+
+		// 4.
+		_, err = tx.ExecContext(ctx, `
+			UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL
+		`, now, token.FamilyID)
+		if err != nil {
+			slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.Exec revoke family", "error", err)
+			return nil, queryError(ctx, err)
+		}
+		if err = tx.Commit(); err != nil {
+			slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.Commit", "error", err)
+			return nil, queryError(ctx, err)
+		}
+
+		slog.WarnContext(ctx, "RefreshTokenModel.RotateRefreshToken - rotated token reused, family revoked",
+			"user_id", token.UserID, "family_id", token.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	// 3.
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, token.UserID, token.FamilyID, newTokenHash, expiresAt.UTC(), now)
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.Exec insert", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RotateRefreshToken - tx.Commit", "error", err)
+		return nil, queryError(ctx, err)
+	}
+
+	return token, nil
+}
+
+func (m *RefreshTokenModel) RevokeRefreshTokenFamily(ctx context.Context, userID int, tokenHash string) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	// The family is looked up first: MySQL cannot update a table it selects from
+	var familyID string
+	query := `SELECT family_id FROM refresh_tokens WHERE token_hash = ? AND user_id = ?`
+	err := m.DB.QueryRowContext(ctx, query, tokenHash, userID).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		slog.ErrorContext(ctx, "RefreshTokenModel.RevokeRefreshTokenFamily - m.DB.QueryRow", "error", err)
+		return queryError(ctx, err)
+	}
+
+	stmt := `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`
+	_, err = m.DB.ExecContext(ctx, stmt, time.Now().UTC(), familyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RevokeRefreshTokenFamily - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
+
+func (m *RefreshTokenModel) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	stmt := `UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	_, err := m.DB.ExecContext(ctx, stmt, time.Now().UTC(), userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "RefreshTokenModel.RevokeUserRefreshTokens - m.DB.Exec", "error", err)
+		return queryError(ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	models "dessert-ordering-go-system/models"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// presented lists the token hashes sent to RotateRefreshToken in turn, each
+		// rotated into the next hash of the chain: "a" -> "b" -> "c" ...
+		presented []string
+		wantErr   error // of the last rotation
+		// wantRevoked is whether the newest token of the family is refused afterwards
+		wantRevoked bool
+	}{
+		{name: "rotate once", presented: []string{"a"}},
+		{name: "rotate twice", presented: []string{"a", "b"}},
+		{name: "unknown token", presented: []string{"x"}, wantErr: models.ErrInvalidRefreshToken},
+		{name: "reuse revokes the family", presented: []string{"a", "a"}, wantErr: models.ErrRefreshTokenReused, wantRevoked: true},
+		{name: "reuse of an older token revokes the family", presented: []string{"a", "b", "a"}, wantErr: models.ErrRefreshTokenReused, wantRevoked: true},
+	}
+
+	for _, s := range stores {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := s.open(t)
+				expiresAt := time.Now().Add(time.Hour)
+
+				userID := createUser(t, repo, "bob@example.com")
+				if err := repo.CreateRefreshToken(ctx, userID, "family-1", "a", expiresAt); err != nil {
+					t.Fatalf("CreateRefreshToken: %v", err)
+				}
+				// Another family of the same user, which reuse must not touch
+				if err := repo.CreateRefreshToken(ctx, userID, "family-2", "other", expiresAt); err != nil {
+					t.Fatalf("CreateRefreshToken: %v", err)
+				}
+
+				var err error
+				latest, next := 'a', 'b'
+				for _, hash := range tt.presented {
+					var token *models.RefreshToken
+					token, err = repo.RotateRefreshToken(ctx, hash, string(next), expiresAt)
+					if err == nil {
+						latest, next = next, next+1
+						if token.FamilyID != "family-1" || token.UserID != userID {
+							t.Errorf("RotateRefreshToken(%s) = family %s of user %d, want family-1 of user %d", hash, token.FamilyID, token.UserID, userID)
+						}
+					}
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("last RotateRefreshToken() error = %v, want %v", err, tt.wantErr)
+				}
+
+				_, err = repo.RotateRefreshToken(ctx, string(latest), "latest-next", expiresAt)
+				if tt.wantRevoked && !errors.Is(err, models.ErrInvalidRefreshToken) {
+					t.Errorf("rotating the newest token %c of a revoked family: error = %v, want %v", latest, err, models.ErrInvalidRefreshToken)
+				}
+				if !tt.wantRevoked && err != nil {
+					t.Errorf("rotating the newest token %c: %v", latest, err)
+				}
+				if _, err := repo.RotateRefreshToken(ctx, "other", "other-next", expiresAt); err != nil {
+					t.Errorf("rotating the other family: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestRotateRefreshTokenRefusesExpiredAndRevoked(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := s.open(t)
+
+			userID := createUser(t, repo, "bob@example.com")
+			if err := repo.CreateRefreshToken(ctx, userID, "family-1", "expired", time.Now().Add(-time.Minute)); err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			if err := repo.CreateRefreshToken(ctx, userID, "family-2", "revoked", time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			if err := repo.RevokeRefreshTokenFamily(ctx, userID, "revoked"); err != nil {
+				t.Fatalf("RevokeRefreshTokenFamily: %v", err)
+			}
+
+			for _, hash := range []string{"expired", "revoked"} {
+				_, err := repo.RotateRefreshToken(ctx, hash, hash+"-next", time.Now().Add(time.Hour))
+				if !errors.Is(err, models.ErrInvalidRefreshToken) {
+					t.Errorf("RotateRefreshToken(%s) error = %v, want %v", hash, err, models.ErrInvalidRefreshToken)
+				}
+			}
+		})
+	}
+}
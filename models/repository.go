@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// The repositories below are the storage contract the services depend on. The
+// SQL models implement them for MySQL and SQLite, and models/memory implements
+// them in process for development and tests.
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, username, email, password string) error
+	AuthenticateByEmail(ctx context.Context, email, password string) (*UserData, error)
+	AuthenticateByUsername(ctx context.Context, username, password string) (*UserData, error)
+	GetUserByID(ctx context.Context, userID int) (*UserData, error)
+	GetUserByEmail(ctx context.Context, email string) (*UserData, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) error
+	// RotateRefreshToken marks the token as used and stores newTokenHash in its
+	// family. It returns the rotated token, ErrInvalidRefreshToken when the token
+	// is unknown, expired or revoked, and ErrRefreshTokenReused, after revoking
+	// the whole family, when it was rotated before.
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (*RefreshToken, error)
+	// RevokeRefreshTokenFamily revokes the family of the user's token, so that
+	// no token of that login works anymore. An unknown token is ignored.
+	RevokeRefreshTokenFamily(ctx context.Context, userID int, tokenHash string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+}
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	// LastPasswordResetSentAt returns when the user's latest link was created,
+	// or the zero time if they have none.
+	LastPasswordResetSentAt(ctx context.Context, userID int) (time.Time, error)
+	// ResetPassword sets the password of the user the token was issued to and
+	// returns their ID. Every reset link of the user stops working. It returns
+	// ErrInvalidPasswordReset when the token is unknown, used or expired.
+	ResetPassword(ctx context.Context, tokenHash, password string) (int, error)
+}
+
+type EmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	// LastEmailVerificationSentAt returns when the user's latest link was
+	// created, or the zero time if they have none.
+	LastEmailVerificationSentAt(ctx context.Context, userID int) (time.Time, error)
+	// VerifyEmail marks the email of the token's user as verified and returns
+	// their ID. It returns ErrInvalidEmailVerification when the token is
+	// unknown or expired.
+	VerifyEmail(ctx context.Context, tokenHash string) (int, error)
+}
+
+type ProductRepository interface {
+	GetAllProducts(ctx context.Context) ([]*Product, error)
+	GetAllProductsIncludingArchived(ctx context.Context) ([]*Product, error)
+	GetProductByID(ctx context.Context, productID int) (*Product, error)
+	CreateProduct(ctx context.Context, input *ProductInput) (*Product, error)
+	UpdateProduct(ctx context.Context, productID int, input *ProductInput) (*Product, error)
+	SetProductArchived(ctx context.Context, productID int, archived bool) (*Product, error)
+	// UpsertProduct creates or updates the product with input.Title and replaces
+	// its images. It reports whether the product was created.
+	UpsertProduct(ctx context.Context, input *ProductInput, images []string) (*Product, bool, error)
+	// ResetCatalog deletes every product along with the carts and orders that
+	// reference them.
+	ResetCatalog(ctx context.Context) error
+}
+
+type ProductImageRepository interface {
+	GetProductImages(ctx context.Context, productID int) ([]*ProductImage, error)
+	GetAllProductImages(ctx context.Context) (map[int][]*ProductImage, error)
+	AddProductImage(ctx context.Context, productID int, image string) (*ProductImage, error)
+	ReorderProductImages(ctx context.Context, productID int, imageIDs []int) ([]*ProductImage, error)
+	RemoveProductImage(ctx context.Context, productID, imageID int) error
+}
+
+type CartRepository interface {
+	GetCartItems(ctx context.Context, userID int) ([]*CartItem, error)
+	AddCartItem(ctx context.Context, userID, productID, quantity int) error
+	RemoveCartItem(ctx context.Context, userID int, cartItemID int) error
+	RemoveSingleCartItem(ctx context.Context, userID, productID int) error
+	ClearCart(ctx context.Context, userID int) error
+	SetCartItemQuantity(ctx context.Context, userID, productID, quantity int) error
+	GetGuestCartItems(ctx context.Context, cart GuestCart) ([]*CartItem, error)
+	MergeGuestCart(ctx context.Context, userID int, cart GuestCart) ([]*ReducedCartItem, error)
+}
+
+type OrderRepository interface {
+	CreateOrderFromCart(ctx context.Context, userID int) (*Order, error)
+	GetOrdersByUser(ctx context.Context, userID, limit, offset int) ([]*Order, int, error)
+	GetOrderByID(ctx context.Context, userID, orderID int) (*Order, error)
+	// UpdateOrderStatus moves the order to status to. With an ownerID, the order
+	// must belong to that user, who may only cancel it while it is pending or
+	// confirmed; the check is made on the locked row.
+	UpdateOrderStatus(ctx context.Context, orderID int, ownerID *int, to OrderStatus, changedBy *int) (*Order, error)
+	GetOrderStatusHistory(ctx context.Context, orderID int) ([]*OrderStatusChange, error)
+	GetOrdersByStatus(ctx context.Context, statuses []OrderStatus) ([]*Order, error)
+}
+
+var (
+	_ UserRepository              = (*UserModel)(nil)
+	_ RefreshTokenRepository      = (*RefreshTokenModel)(nil)
+	_ PasswordResetRepository     = (*PasswordResetModel)(nil)
+	_ EmailVerificationRepository = (*EmailVerificationModel)(nil)
+	_ ProductRepository           = (*ProductModel)(nil)
+	_ ProductImageRepository      = (*ProductImageModel)(nil)
+	_ CartRepository              = (*CartItemModel)(nil)
+	_ OrderRepository             = (*OrderModel)(nil)
+)
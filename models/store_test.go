@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	migrations "dessert-ordering-go-system/internal/migrations"
+	models "dessert-ordering-go-system/models"
+	memory "dessert-ordering-go-system/models/memory"
+)
+
+// store holds the repositories under test, backed by one database.
+type store struct {
+	models.UserRepository
+	models.ProductRepository
+	models.CartRepository
+	models.OrderRepository
+	models.RefreshTokenRepository
+}
+
+// stores lists the in-memory store and the SQL models over a migrated SQLite
+// database, so that every test checks both implementations behave the same.
+var stores = []struct {
+	name string
+	open func(t *testing.T) store
+}{
+	{
+		name: "memory",
+		open: func(t *testing.T) store {
+			s := memory.NewStore()
+			return store{s, s, s, s, s}
+		},
+	},
+	{
+		name: "sqlite",
+		open: func(t *testing.T) store {
+			db := openSQLite(t)
+			return store{
+				&models.UserModel{DB: db},
+				&models.ProductModel{DB: db, Dialect: models.DialectSQLite},
+				&models.CartItemModel{DB: db},
+				&models.OrderModel{DB: db, Dialect: models.DialectSQLite},
+				&models.RefreshTokenModel{DB: db},
+			}
+		},
+	},
+}
+
+// openSQLite returns a migrated SQLite database that lives as long as the test.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// Every connection to :memory: opens a new, empty database
+	db.SetMaxOpenConns(1)
+
+	migrator, err := migrations.NewMigrator(db, models.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	return db
+}
+
+// createUser registers a user and returns its ID.
+func createUser(t *testing.T, s store, email string) int {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := s.CreateUser(ctx, email, email, "secret12"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user, err := s.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	return user.ID
+}
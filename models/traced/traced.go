@@ -0,0 +1,342 @@
+// Package traced wraps the repositories with OpenTelemetry spans, so that a
+// trace shows which model calls a request made and how long each one took,
+// whichever storage backend is in use.
+package traced
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	models "dessert-ordering-go-system/models"
+)
+
+var tracer = otel.Tracer("dessert-ordering-go-system/models")
+
+// end records err, if any, on span and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func NewUserRepository(next models.UserRepository) models.UserRepository {
+	return &userRepository{next}
+}
+
+func NewRefreshTokenRepository(next models.RefreshTokenRepository) models.RefreshTokenRepository {
+	return &refreshTokenRepository{next}
+}
+
+func NewPasswordResetRepository(next models.PasswordResetRepository) models.PasswordResetRepository {
+	return &passwordResetRepository{next}
+}
+
+func NewEmailVerificationRepository(next models.EmailVerificationRepository) models.EmailVerificationRepository {
+	return &emailVerificationRepository{next}
+}
+
+func NewProductRepository(next models.ProductRepository) models.ProductRepository {
+	return &productRepository{next}
+}
+
+func NewProductImageRepository(next models.ProductImageRepository) models.ProductImageRepository {
+	return &productImageRepository{next}
+}
+
+func NewCartRepository(next models.CartRepository) models.CartRepository {
+	return &cartRepository{next}
+}
+
+func NewOrderRepository(next models.OrderRepository) models.OrderRepository {
+	return &orderRepository{next}
+}
+
+type userRepository struct {
+	next models.UserRepository
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, username, email, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.CreateUser")
+	defer func() { end(span, err) }()
+	return r.next.CreateUser(ctx, username, email, password)
+}
+
+func (r *userRepository) AuthenticateByEmail(ctx context.Context, email, password string) (_ *models.UserData, err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.AuthenticateByEmail")
+	defer func() { end(span, err) }()
+	return r.next.AuthenticateByEmail(ctx, email, password)
+}
+
+func (r *userRepository) AuthenticateByUsername(ctx context.Context, username, password string) (_ *models.UserData, err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.AuthenticateByUsername")
+	defer func() { end(span, err) }()
+	return r.next.AuthenticateByUsername(ctx, username, password)
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, userID int) (_ *models.UserData, err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetUserByID")
+	defer func() { end(span, err) }()
+	return r.next.GetUserByID(ctx, userID)
+}
+
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (_ *models.UserData, err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetUserByEmail")
+	defer func() { end(span, err) }()
+	return r.next.GetUserByEmail(ctx, email)
+}
+
+type refreshTokenRepository struct {
+	next models.RefreshTokenRepository
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, userID int, familyID, tokenHash string, expiresAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "RefreshTokenRepository.CreateRefreshToken")
+	defer func() { end(span, err) }()
+	return r.next.CreateRefreshToken(ctx, userID, familyID, tokenHash, expiresAt)
+}
+
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (_ *models.RefreshToken, err error) {
+	ctx, span := tracer.Start(ctx, "RefreshTokenRepository.RotateRefreshToken")
+	defer func() { end(span, err) }()
+	return r.next.RotateRefreshToken(ctx, tokenHash, newTokenHash, expiresAt)
+}
+
+func (r *refreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, userID int, tokenHash string) (err error) {
+	ctx, span := tracer.Start(ctx, "RefreshTokenRepository.RevokeRefreshTokenFamily")
+	defer func() { end(span, err) }()
+	return r.next.RevokeRefreshTokenFamily(ctx, userID, tokenHash)
+}
+
+func (r *refreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) (err error) {
+	ctx, span := tracer.Start(ctx, "RefreshTokenRepository.RevokeUserRefreshTokens")
+	defer func() { end(span, err) }()
+	return r.next.RevokeUserRefreshTokens(ctx, userID)
+}
+
+type emailVerificationRepository struct {
+	next models.EmailVerificationRepository
+}
+
+func (r *emailVerificationRepository) CreateEmailVerification(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "EmailVerificationRepository.CreateEmailVerification")
+	defer func() { end(span, err) }()
+	return r.next.CreateEmailVerification(ctx, userID, tokenHash, expiresAt)
+}
+
+func (r *emailVerificationRepository) LastEmailVerificationSentAt(ctx context.Context, userID int) (_ time.Time, err error) {
+	ctx, span := tracer.Start(ctx, "EmailVerificationRepository.LastEmailVerificationSentAt")
+	defer func() { end(span, err) }()
+	return r.next.LastEmailVerificationSentAt(ctx, userID)
+}
+
+func (r *emailVerificationRepository) VerifyEmail(ctx context.Context, tokenHash string) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "EmailVerificationRepository.VerifyEmail")
+	defer func() { end(span, err) }()
+	return r.next.VerifyEmail(ctx, tokenHash)
+}
+
+type passwordResetRepository struct {
+	next models.PasswordResetRepository
+}
+
+func (r *passwordResetRepository) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "PasswordResetRepository.CreatePasswordReset")
+	defer func() { end(span, err) }()
+	return r.next.CreatePasswordReset(ctx, userID, tokenHash, expiresAt)
+}
+
+func (r *passwordResetRepository) LastPasswordResetSentAt(ctx context.Context, userID int) (_ time.Time, err error) {
+	ctx, span := tracer.Start(ctx, "PasswordResetRepository.LastPasswordResetSentAt")
+	defer func() { end(span, err) }()
+	return r.next.LastPasswordResetSentAt(ctx, userID)
+}
+
+func (r *passwordResetRepository) ResetPassword(ctx context.Context, tokenHash, password string) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "PasswordResetRepository.ResetPassword")
+	defer func() { end(span, err) }()
+	return r.next.ResetPassword(ctx, tokenHash, password)
+}
+
+type productRepository struct {
+	next models.ProductRepository
+}
+
+func (r *productRepository) GetAllProducts(ctx context.Context) (_ []*models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.GetAllProducts")
+	defer func() { end(span, err) }()
+	return r.next.GetAllProducts(ctx)
+}
+
+func (r *productRepository) GetAllProductsIncludingArchived(ctx context.Context) (_ []*models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.GetAllProductsIncludingArchived")
+	defer func() { end(span, err) }()
+	return r.next.GetAllProductsIncludingArchived(ctx)
+}
+
+func (r *productRepository) GetProductByID(ctx context.Context, productID int) (_ *models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.GetProductByID")
+	defer func() { end(span, err) }()
+	return r.next.GetProductByID(ctx, productID)
+}
+
+func (r *productRepository) CreateProduct(ctx context.Context, input *models.ProductInput) (_ *models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.CreateProduct")
+	defer func() { end(span, err) }()
+	return r.next.CreateProduct(ctx, input)
+}
+
+func (r *productRepository) UpdateProduct(ctx context.Context, productID int, input *models.ProductInput) (_ *models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.UpdateProduct")
+	defer func() { end(span, err) }()
+	return r.next.UpdateProduct(ctx, productID, input)
+}
+
+func (r *productRepository) SetProductArchived(ctx context.Context, productID int, archived bool) (_ *models.Product, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.SetProductArchived")
+	defer func() { end(span, err) }()
+	return r.next.SetProductArchived(ctx, productID, archived)
+}
+
+func (r *productRepository) UpsertProduct(ctx context.Context, input *models.ProductInput, images []string) (_ *models.Product, _ bool, err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.UpsertProduct")
+	defer func() { end(span, err) }()
+	return r.next.UpsertProduct(ctx, input, images)
+}
+
+func (r *productRepository) ResetCatalog(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductRepository.ResetCatalog")
+	defer func() { end(span, err) }()
+	return r.next.ResetCatalog(ctx)
+}
+
+type productImageRepository struct {
+	next models.ProductImageRepository
+}
+
+func (r *productImageRepository) GetProductImages(ctx context.Context, productID int) (_ []*models.ProductImage, err error) {
+	ctx, span := tracer.Start(ctx, "ProductImageRepository.GetProductImages")
+	defer func() { end(span, err) }()
+	return r.next.GetProductImages(ctx, productID)
+}
+
+func (r *productImageRepository) GetAllProductImages(ctx context.Context) (_ map[int][]*models.ProductImage, err error) {
+	ctx, span := tracer.Start(ctx, "ProductImageRepository.GetAllProductImages")
+	defer func() { end(span, err) }()
+	return r.next.GetAllProductImages(ctx)
+}
+
+func (r *productImageRepository) AddProductImage(ctx context.Context, productID int, image string) (_ *models.ProductImage, err error) {
+	ctx, span := tracer.Start(ctx, "ProductImageRepository.AddProductImage")
+	defer func() { end(span, err) }()
+	return r.next.AddProductImage(ctx, productID, image)
+}
+
+func (r *productImageRepository) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) (_ []*models.ProductImage, err error) {
+	ctx, span := tracer.Start(ctx, "ProductImageRepository.ReorderProductImages")
+	defer func() { end(span, err) }()
+	return r.next.ReorderProductImages(ctx, productID, imageIDs)
+}
+
+func (r *productImageRepository) RemoveProductImage(ctx context.Context, productID, imageID int) (err error) {
+	ctx, span := tracer.Start(ctx, "ProductImageRepository.RemoveProductImage")
+	defer func() { end(span, err) }()
+	return r.next.RemoveProductImage(ctx, productID, imageID)
+}
+
+type cartRepository struct {
+	next models.CartRepository
+}
+
+func (r *cartRepository) GetCartItems(ctx context.Context, userID int) (_ []*models.CartItem, err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.GetCartItems")
+	defer func() { end(span, err) }()
+	return r.next.GetCartItems(ctx, userID)
+}
+
+func (r *cartRepository) AddCartItem(ctx context.Context, userID, productID, quantity int) (err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.AddCartItem")
+	defer func() { end(span, err) }()
+	return r.next.AddCartItem(ctx, userID, productID, quantity)
+}
+
+func (r *cartRepository) RemoveCartItem(ctx context.Context, userID int, cartItemID int) (err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.RemoveCartItem")
+	defer func() { end(span, err) }()
+	return r.next.RemoveCartItem(ctx, userID, cartItemID)
+}
+
+func (r *cartRepository) RemoveSingleCartItem(ctx context.Context, userID, productID int) (err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.RemoveSingleCartItem")
+	defer func() { end(span, err) }()
+	return r.next.RemoveSingleCartItem(ctx, userID, productID)
+}
+
+func (r *cartRepository) ClearCart(ctx context.Context, userID int) (err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.ClearCart")
+	defer func() { end(span, err) }()
+	return r.next.ClearCart(ctx, userID)
+}
+
+func (r *cartRepository) SetCartItemQuantity(ctx context.Context, userID, productID, quantity int) (err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.SetCartItemQuantity")
+	defer func() { end(span, err) }()
+	return r.next.SetCartItemQuantity(ctx, userID, productID, quantity)
+}
+
+func (r *cartRepository) GetGuestCartItems(ctx context.Context, cart models.GuestCart) (_ []*models.CartItem, err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.GetGuestCartItems")
+	defer func() { end(span, err) }()
+	return r.next.GetGuestCartItems(ctx, cart)
+}
+
+func (r *cartRepository) MergeGuestCart(ctx context.Context, userID int, cart models.GuestCart) (_ []*models.ReducedCartItem, err error) {
+	ctx, span := tracer.Start(ctx, "CartRepository.MergeGuestCart")
+	defer func() { end(span, err) }()
+	return r.next.MergeGuestCart(ctx, userID, cart)
+}
+
+type orderRepository struct {
+	next models.OrderRepository
+}
+
+func (r *orderRepository) CreateOrderFromCart(ctx context.Context, userID int) (_ *models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.CreateOrderFromCart")
+	defer func() { end(span, err) }()
+	return r.next.CreateOrderFromCart(ctx, userID)
+}
+
+func (r *orderRepository) GetOrdersByUser(ctx context.Context, userID, limit, offset int) (_ []*models.Order, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.GetOrdersByUser")
+	defer func() { end(span, err) }()
+	return r.next.GetOrdersByUser(ctx, userID, limit, offset)
+}
+
+func (r *orderRepository) GetOrderByID(ctx context.Context, userID, orderID int) (_ *models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.GetOrderByID")
+	defer func() { end(span, err) }()
+	return r.next.GetOrderByID(ctx, userID, orderID)
+}
+
+func (r *orderRepository) UpdateOrderStatus(ctx context.Context, orderID int, ownerID *int, to models.OrderStatus, changedBy *int) (_ *models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.UpdateOrderStatus")
+	defer func() { end(span, err) }()
+	return r.next.UpdateOrderStatus(ctx, orderID, ownerID, to, changedBy)
+}
+
+func (r *orderRepository) GetOrderStatusHistory(ctx context.Context, orderID int) (_ []*models.OrderStatusChange, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.GetOrderStatusHistory")
+	defer func() { end(span, err) }()
+	return r.next.GetOrderStatusHistory(ctx, orderID)
+}
+
+func (r *orderRepository) GetOrdersByStatus(ctx context.Context, statuses []models.OrderStatus) (_ []*models.Order, err error) {
+	ctx, span := tracer.Start(ctx, "OrderRepository.GetOrdersByStatus")
+	defer func() { end(span, err) }()
+	return r.next.GetOrdersByStatus(ctx, statuses)
+}
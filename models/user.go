@@ -1,74 +1,106 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+// IsValid reports whether r is one of the known roles.
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Hash      string    `json:"hash"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Hash     string `json:"hash"`
+	Role     Role   `json:"role"`
+	// VerifiedAt is when the user confirmed their email, nil until they do
+	VerifiedAt *time.Time `json:"verifiedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
 }
 
 type UserData struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     Role   `json:"role"`
+	// VerifiedAt is when the user confirmed their email, nil until they do
+	VerifiedAt *time.Time `json:"verifiedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
 }
 
 type UserModel struct {
-	DB *sql.DB
+	DB           *sql.DB
+	QueryTimeout time.Duration
 }
 
-func (m *UserModel) CreateUser(username, email, password string) error {
+func (m *UserModel) CreateUser(ctx context.Context, username, email, password string) error {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1 Hash the plaintext password
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("ERROR: m.UserModel.CreateUser - bcrypt.GeneratePassword: %v", err)
+		slog.ErrorContext(ctx, "m.UserModel.CreateUser - bcrypt.GeneratePassword", "error", err)
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	stmt := `
-		INSERT INTO users (username, email, hash, created_at, updated_at) 
-		VALUES (?, ?, ?, UTC_TIMESTAMP(), UTC_TIMESTAMP())
+		INSERT INTO users (username, email, hash, role, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	_, err = m.DB.Exec(stmt, username, email, hashPassword)
+	now := time.Now().UTC()
+	_, err = m.DB.ExecContext(ctx, stmt, username, email, hashPassword, RoleCustomer, now, now)
 	if err != nil {
 		if IsDuplicateEntryError(err) {
 			return ErrDuplicateRecord
 		}
-		log.Printf("ERROR: m.UserModel.CreateUser - m.DB.Exec: %v", err)
-		return fmt.Errorf("failed to insert user: %w", err)
+		slog.ErrorContext(ctx, "m.UserModel.CreateUser - m.DB.Exec", "error", err)
+		return queryError(ctx, fmt.Errorf("failed to insert user: %w", err))
 	}
 
 	return nil
 }
 
-func (m *UserModel) AuthenticateByEmail(email, password string) (*UserData, error) {
+func (m *UserModel) AuthenticateByEmail(ctx context.Context, email, password string) (*UserData, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1. Retrieve the user data
 	var user *User = &User{}
 
-	query := `SELECT id, username, email, hash, created_at, updated_at FROM users WHERE email = ?`
+	query := `SELECT id, username, email, hash, role, verified_at, created_at, updated_at FROM users WHERE email = ?`
 
-	row := m.DB.QueryRow(query, email)
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Hash, &user.CreatedAt, &user.UpdatedAt)
+	row := m.DB.QueryRowContext(ctx, query, email)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Hash, &user.Role, &user.VerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrInvalidCredentials
 		}
-		log.Printf("ERROR: m.UserModel.Authenticate - m.QueryRow: %v", err)
-		return nil, fmt.Errorf("invalid authentication credentials: %w", err)
+		slog.ErrorContext(ctx, "m.UserModel.Authenticate - m.QueryRow", "error", err)
+		return nil, queryError(ctx, fmt.Errorf("invalid authentication credentials: %w", err))
 	}
 
 	// 2. Compare the password
@@ -77,7 +109,7 @@ func (m *UserModel) AuthenticateByEmail(email, password string) (*UserData, erro
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return nil, ErrInvalidCredentials
 		}
-		log.Printf("ERROR: m.UserModel.Authenticate - bcrypt.CompareHashAndPassword: %v", err)
+		slog.ErrorContext(ctx, "m.UserModel.Authenticate - bcrypt.CompareHashAndPassword", "error", err)
 		return nil, fmt.Errorf("failed to compare hash and password: %w", err)
 	}
 
@@ -85,6 +117,8 @@ func (m *UserModel) AuthenticateByEmail(email, password string) (*UserData, erro
 		ID: user.ID,
 		Username: user.Username,
 		Email: user.Email,
+		Role: user.Role,
+		VerifiedAt: user.VerifiedAt,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
@@ -92,20 +126,23 @@ func (m *UserModel) AuthenticateByEmail(email, password string) (*UserData, erro
 	return userData, nil
 }
 
-func (m *UserModel) AuthenticateByUsername(username, password string) (*UserData, error) {
+func (m *UserModel) AuthenticateByUsername(ctx context.Context, username, password string) (*UserData, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
 	// 1. Retrieve the user data
 	var user *User = &User{}
 
-	query := `SELECT id, username, email, hash, created_at, updated_at FROM users WHERE username = ?`
+	query := `SELECT id, username, email, hash, role, verified_at, created_at, updated_at FROM users WHERE username = ?`
 
-	row := m.DB.QueryRow(query, username)
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Hash, &user.CreatedAt, &user.UpdatedAt)
+	row := m.DB.QueryRowContext(ctx, query, username)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Hash, &user.Role, &user.VerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrInvalidCredentials
 		}
-		log.Printf("ERROR: m.UserModel.Authenticate - m.QueryRow: %v", err)
-		return nil, fmt.Errorf("invalid authentication credentials: %w", err)
+		slog.ErrorContext(ctx, "m.UserModel.Authenticate - m.QueryRow", "error", err)
+		return nil, queryError(ctx, fmt.Errorf("invalid authentication credentials: %w", err))
 	}
 
 	// 2. Compare the password
@@ -114,7 +151,7 @@ func (m *UserModel) AuthenticateByUsername(username, password string) (*UserData
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return nil, ErrInvalidCredentials
 		}
-		log.Printf("ERROR: m.UserModel.Authenticate - bcrypt.CompareHashAndPassword: %v", err)
+		slog.ErrorContext(ctx, "m.UserModel.Authenticate - bcrypt.CompareHashAndPassword", "error", err)
 		return nil, fmt.Errorf("failed to compare hash and password: %w", err)
 	}
 
@@ -122,9 +159,53 @@ func (m *UserModel) AuthenticateByUsername(username, password string) (*UserData
 		ID: user.ID,
 		Username: user.Username,
 		Email: user.Email,
+		Role: user.Role,
+		VerifiedAt: user.VerifiedAt,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
 
 	return userData, nil
-}
\ No newline at end of file
+}
+
+func (m *UserModel) GetUserByID(ctx context.Context, userID int) (*UserData, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	var user *UserData = &UserData{}
+
+	query := `SELECT id, username, email, role, verified_at, created_at, updated_at FROM users WHERE id = ?`
+
+	row := m.DB.QueryRowContext(ctx, query, userID)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.VerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		slog.ErrorContext(ctx, "m.UserModel.GetUserByID - m.QueryRow", "error", err)
+		return nil, queryError(ctx, fmt.Errorf("failed to load user: %w", err))
+	}
+
+	return user, nil
+}
+
+func (m *UserModel) GetUserByEmail(ctx context.Context, email string) (*UserData, error) {
+	ctx, cancel := queryContext(ctx, m.QueryTimeout)
+	defer cancel()
+
+	var user *UserData = &UserData{}
+
+	query := `SELECT id, username, email, role, verified_at, created_at, updated_at FROM users WHERE email = ?`
+
+	row := m.DB.QueryRowContext(ctx, query, email)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.VerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		slog.ErrorContext(ctx, "m.UserModel.GetUserByEmail - m.QueryRow", "error", err)
+		return nil, queryError(ctx, fmt.Errorf("failed to load user: %w", err))
+	}
+
+	return user, nil
+}
@@ -9,25 +9,29 @@ import (
 
 	"dessert-ordering-go-system/handlers"
 	"dessert-ordering-go-system/internal/app"
+	logging "dessert-ordering-go-system/internal/logging"
 	responses "dessert-ordering-go-system/internal/response"
+	tracing "dessert-ordering-go-system/internal/tracing"
 	middlewares "dessert-ordering-go-system/middlewares"
+	models "dessert-ordering-go-system/models"
 )
 
 func NewRoutes(a *app.Application) *chi.Mux {
 	r := chi.NewRouter()
 
 	// --- Chi's Built-in Middlewares (Commonly used) ---
-	r.Use(middleware.Logger)          // Log Requests
-	r.Use(middleware.RedirectSlashes) // Support Trailing Slash Requests
-	r.Use(middleware.RequestID)       // Adds a request ID to the context
-	r.Use(middleware.RealIP)          // Safely extracts the client IP address
-	r.Use(middleware.Recoverer)       // Recovers from panics and logs them, prevent server crash
-	r.Use(a.Session.LoadAndSave)      // Helps load and save the session automatically
+	r.Use(middleware.RequestID)         // Adds a request ID to the context
+	r.Use(middleware.RealIP)            // Safely extracts the client IP address
+	r.Use(tracing.Middleware)           // Start a span per Request, named after its route
+	r.Use(logging.Middleware(a.Logger)) // Log Requests with their request ID, route and user
+	r.Use(a.Metrics.Middleware)         // Count and time Requests by route
+	r.Use(middleware.RedirectSlashes)   // Support Trailing Slash Requests
+	r.Use(middleware.Recoverer)         // Recovers from panics and logs them, prevent server crash
 
 	// --- YOUR CUSTOM 404 HANDLER ---
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		// You can log the fact that a 404 occurred for debugging
-		a.Loggers.Error.Printf("404 Not Found: %s %s", r.Method, r.URL.Path)
+		a.Logger.WarnContext(r.Context(), "404 Not Found", "method", r.Method, "path", r.URL.Path)
 
 		// Use your existing JSON response structure
 		response := responses.NewErrorJsonResponse(fmt.Sprintf("The requested resource '%s' was not found.", r.URL.Path))
@@ -37,7 +41,7 @@ func NewRoutes(a *app.Application) *chi.Mux {
 	// --- YOUR CUSTOM 405 HANDLER (Optional, but good practice) ---
 	// This handles cases where the path exists but the HTTP method is not allowed.
 	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
-		a.Loggers.Error.Printf("405 Method Not Allowed: %s %s", r.Method, r.URL.Path)
+		a.Logger.WarnContext(r.Context(), "405 Method Not Allowed", "method", r.Method, "path", r.URL.Path)
 		response := responses.NewErrorJsonResponse(fmt.Sprintf("Method '%s' not allowed for this resource.", r.Method))
 		responses.WriteJsonResponse(w, http.StatusMethodNotAllowed, response)
 	})
@@ -54,8 +58,26 @@ func NewRoutes(a *app.Application) *chi.Mux {
 	customMiddlewares := middlewares.NewMiddlewares(a)
 	handlers := handlers.NewWebHandlers(a)
 
+	// Health Checks (no session, authentication or CSRF, so that probes keep
+	// working when Redis is down)
+	r.Get("/healthz", handlers.HealthzHandler)
+	r.Get("/readyz", handlers.ReadyzHandler)
+
+	// Metrics, unless they are served on a separate admin listener (see main.go)
+	if a.Config.Metrics.Addr == "" {
+		r.Handle("/metrics", a.Metrics.Handler(a.Config.Metrics.Token))
+	}
+
+	// Public keys of the JWT signatures, for services that verify the tokens
+	r.Get("/.well-known/jwks.json", handlers.JWKSHandler)
+
+	// Refresh Tokens (API clients trade a refresh token for a new JWT; the
+	// token is sent in the body, so no session or CSRF token is involved)
+	r.Post("/auth/refresh", handlers.RefreshTokenHandler)
+
 	// Authentication Not Required
 	r.Group(func(r chi.Router) {
+		r.Use(a.Session.LoadAndSave) // Helps load and save the session automatically
 		r.Use(customMiddlewares.AuthNotRequired)
 
 		r.Get("/login", handlers.GetLoginHandler)
@@ -63,24 +85,58 @@ func NewRoutes(a *app.Application) *chi.Mux {
 
 		r.Get("/register", handlers.GetRegisterHandler)
 		r.Post("/register", handlers.PostRegisterHandler)
+
+		r.Get("/forgot-password", handlers.GetForgotPasswordHandler)
+		r.Post("/forgot-password", handlers.PostForgotPasswordHandler)
+
+		r.Get("/reset-password", handlers.GetResetPasswordHandler)
+		r.Post("/reset-password", handlers.PostResetPasswordHandler)
 	})
 
-	// Authentication Required
+	// Authentication Optional (anonymous visitors get a guest cart in their session)
 	r.Group(func(r chi.Router) {
-		r.Use(customMiddlewares.AuthRequired)
+		r.Use(a.Session.LoadAndSave)
+		r.Use(customMiddlewares.AuthOptional)
 		r.Group(func(r chi.Router) {
 			r.Use(customMiddlewares.EnableCSRF)
 
 			r.Get("/", handlers.HomeHandler)
 
+			r.Get("/verify-email", handlers.VerifyEmailHandler)
+
 			r.Get("/products", handlers.GetProductsHandler)
 			r.Get("/products/{id}", handlers.GetProductDetailHandler)
 
 			r.Get("/cart", handlers.GetCartHandler)
 			r.Get("/cart/product/{product_id}/remove-one", handlers.RedirectToHomeHandler) // Just in case the user refreshes
 			r.Get("/cart/{item_id}/delete", handlers.RedirectToHomeHandler)                // Just in case the user refreshes
+			r.Get("/cart/product/{product_id}", handlers.RedirectToHomeHandler)            // Just in case the user refreshes
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddlewares.RequireCSRF) // Apply require CSRF middlewares to all routes in this group
+
+			r.Post("/cart/{item_id}/delete", handlers.RemoveCartItemHandler)
+			r.Post("/cart/product/{product_id}/remove-one", handlers.RemoveSingleCartItemHandler)
+			r.Put("/cart/product/{product_id}", handlers.SetCartItemQuantityHandler)
+			r.Post("/cart/product/{product_id}", handlers.SetCartItemQuantityHandler) // HTML forms cannot send PUT
+			r.Post("/cart", handlers.AddCartItemHandler)
+		})
+	})
+
+	// Authentication Required
+	r.Group(func(r chi.Router) {
+		r.Use(a.Session.LoadAndSave)
+		r.Use(customMiddlewares.AuthRequired)
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddlewares.EnableCSRF)
+
 			r.Get("/confirm-order", handlers.ConfirmOrderHandler)
 			r.Get("/checkout", handlers.RedirectToHomeHandler)
+
+			r.Get("/orders", handlers.GetOrdersHandler)
+			r.Get("/orders/{id}", handlers.GetOrderDetailHandler)
+			r.Get("/orders/{id}/cancel", handlers.RedirectToHomeHandler) // Just in case the user refreshes
 		})
 
 		r.Group(func(r chi.Router) {
@@ -88,10 +144,54 @@ func NewRoutes(a *app.Application) *chi.Mux {
 
 			r.Get("/logout", handlers.RedirectToHomeHandler)
 			r.Post("/logout", handlers.LogoutHandler)
-			r.Post("/cart/{item_id}/delete", handlers.RemoveCartItemHandler)
-			r.Post("/cart/product/{product_id}/remove-one", handlers.RemoveSingleCartItemHandler)
-			r.Post("/cart", handlers.AddCartItemHandler)
+			r.Get("/logout-everywhere", handlers.RedirectToHomeHandler)
+			r.Post("/logout-everywhere", handlers.LogoutEverywhereHandler)
+			r.Get("/verify-email/resend", handlers.RedirectToHomeHandler)
+			r.Post("/verify-email/resend", handlers.ResendVerificationEmailHandler)
 			r.Post("/checkout", handlers.CheckoutHandler)
+			r.Post("/orders/{id}/cancel", handlers.CancelOrderHandler)
+		})
+
+		// Admin Catalog Management
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(customMiddlewares.RequireRole(models.RoleAdmin))
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddlewares.EnableCSRF)
+
+				r.Get("/products", handlers.GetAdminProductsHandler)
+				r.Get("/products/{id}", handlers.GetAdminProductHandler)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddlewares.RequireCSRF)
+
+				r.Post("/products", handlers.CreateProductHandler)
+				r.Post("/products/{id}", handlers.UpdateProductHandler)
+				r.Post("/products/{id}/archive", handlers.ArchiveProductHandler)
+				r.Post("/products/{id}/restore", handlers.RestoreProductHandler)
+				r.Post("/products/{id}/images", handlers.AddProductImageHandler)
+				r.Post("/products/{id}/images/order", handlers.ReorderProductImagesHandler)
+				r.Post("/products/{id}/images/{image_id}/delete", handlers.RemoveProductImageHandler)
+			})
+		})
+
+		// Kitchen Order Queue
+		r.Route("/kitchen", func(r chi.Router) {
+			r.Use(customMiddlewares.RequireRole(models.RoleStaff, models.RoleAdmin))
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddlewares.EnableCSRF)
+
+				r.Get("/orders", handlers.GetKitchenOrdersHandler)
+				r.Get("/orders/{id}/status", handlers.RedirectToHomeHandler) // Just in case the user refreshes
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(customMiddlewares.RequireCSRF)
+
+				r.Post("/orders/{id}/status", handlers.UpdateKitchenOrderStatusHandler)
+			})
 		})
 	})
 
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"dessert-ordering-go-system/models"
+)
+
+type AdminProductForDisplay struct {
+	Product       *models.ProductForDisplay
+	ProductImages []*models.ProductImage
+	IsArchived    bool
+}
+
+type AdminProductsTemplateData struct {
+	CsrfToken string
+	Errors    []string
+	Messages  []string
+	Products  []AdminProductForDisplay
+	Form      *ProductForm
+}
+
+func (c AdminProductsTemplateData) String() string {
+	return fmt.Sprintf("CsrfToken: %v, Errors [%v], Messages [%v], Products [%v]",
+		c.CsrfToken,
+		len(c.Errors),
+		len(c.Messages),
+		len(c.Products),
+	)
+}
+
+type AdminProductsTemplateDataService struct {
+	ProductService *ProductService
+}
+
+type GetAdminProductsTemplateContentOptionsFunc func(*AdminProductsTemplateData)
+
+func NewAdminProductsTemplateDataService(productService *ProductService) *AdminProductsTemplateDataService {
+	return &AdminProductsTemplateDataService{
+		ProductService: productService,
+	}
+}
+
+func (s *AdminProductsTemplateDataService) WithCsrfToken(csrfToken string) GetAdminProductsTemplateContentOptionsFunc {
+	return func(opts *AdminProductsTemplateData) {
+		opts.CsrfToken = csrfToken
+	}
+}
+
+func (s *AdminProductsTemplateDataService) WithErrors(errs []string) GetAdminProductsTemplateContentOptionsFunc {
+	return func(opts *AdminProductsTemplateData) {
+		opts.Errors = append(opts.Errors, errs...)
+	}
+}
+
+func (s *AdminProductsTemplateDataService) WithForm(form *ProductForm) GetAdminProductsTemplateContentOptionsFunc {
+	return func(opts *AdminProductsTemplateData) {
+		opts.Form = form
+	}
+}
+
+func (s *AdminProductsTemplateDataService) GetAdminProductsTemplateContent(ctx context.Context, opts ...GetAdminProductsTemplateContentOptionsFunc) (*AdminProductsTemplateData, error) {
+	ctx, span := tracer.Start(ctx, "AdminProductsTemplateDataService.GetAdminProductsTemplateContent")
+	defer span.End()
+
+	var templateContent *AdminProductsTemplateData = &AdminProductsTemplateData{}
+
+	for _, fn := range opts {
+		fn(templateContent)
+	}
+
+	products, err := s.ProductService.GetAdminProducts(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "AdminProductsTemplateDataService.GetAdminProductsTemplateContent - Failed to get products", "error", err)
+		return nil, fmt.Errorf("failed to load product catalog: %w", err)
+	}
+
+	displayProducts := make([]AdminProductForDisplay, 0, len(products))
+	for _, product := range products {
+		displayProducts = append(displayProducts, AdminProductForDisplay{
+			Product:       models.NewProductForDisplay(product.Product),
+			ProductImages: product.ProductImages,
+			IsArchived:    product.IsArchived(),
+		})
+	}
+
+	if templateContent.Errors == nil {
+		templateContent.Errors = []string{}
+	}
+	if templateContent.Form == nil {
+		templateContent.Form = &ProductForm{}
+	}
+	templateContent.Messages = []string{}
+	templateContent.Products = displayProducts
+
+	return templateContent, nil
+}
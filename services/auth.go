@@ -1,41 +1,74 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
-	appConstants "dessert-ordering-go-system/internal/app_constants"
+	jwtkeys "dessert-ordering-go-system/internal/jwtkeys"
+	utils "dessert-ordering-go-system/internal/utils"
 	models "dessert-ordering-go-system/models"
 )
 
 type AuthService struct {
-	UserModel *models.UserModel
-	JWTSecret []byte
+	UserModel              models.UserRepository
+	RefreshTokenModel      models.RefreshTokenRepository
+	Denylist               TokenDenylist
+	JWTSecret              []byte
+	TokenExpiration        time.Duration
+	RefreshTokenExpiration time.Duration
+	// Keys signs tokens with RS256 or EdDSA instead of JWTSecret when set
+	Keys *jwtkeys.Keyring
 }
 
 type AuthData struct {
-	User  models.UserData `json:"user"`
-	Token string          `json:"token"`
+	User         models.UserData `json:"user"`
+	Token        string          `json:"token"`
+	RefreshToken string          `json:"refreshToken,omitempty"`
 }
 
+type RefreshForm struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// LogoutForm is the optional JSON body of a logout. API clients send the
+// refresh token they got at login so that it is revoked too.
+type LogoutForm struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// UserClaims are the claims of the JWTs given to users. ID is the user's ID;
+// the token's own ID, the jti, is RegisteredClaims.ID.
 type UserClaims struct {
-	ID       int    `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	ID       int         `json:"id"`
+	Username string      `json:"username"`
+	Email    string      `json:"email"`
+	Role     models.Role `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(userModel *models.UserModel, jwtSecret string) *AuthService {
+func NewAuthService(userModel models.UserRepository, refreshTokenModel models.RefreshTokenRepository, denylist TokenDenylist, jwtSecret string, keys *jwtkeys.Keyring, tokenExpiration, refreshTokenExpiration time.Duration) *AuthService {
 	return &AuthService{
-		UserModel: userModel,
-		JWTSecret: []byte(jwtSecret),
+		UserModel:              userModel,
+		RefreshTokenModel:      refreshTokenModel,
+		Denylist:               denylist,
+		JWTSecret:              []byte(jwtSecret),
+		Keys:                   keys,
+		TokenExpiration:        tokenExpiration,
+		RefreshTokenExpiration: refreshTokenExpiration,
 	}
 }
 
-func (a *AuthService) Authenticate(contact, password string) (*models.UserData, error) {
+func (a *AuthService) Authenticate(ctx context.Context, contact, password string) (*models.UserData, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+
 	var (
 		err      error
 		userData *models.UserData
@@ -43,38 +76,65 @@ func (a *AuthService) Authenticate(contact, password string) (*models.UserData,
 
 	// 1. Check if the contact is an email or username
 	if strings.Contains(contact, "@") {
-		userData, err = a.UserModel.AuthenticateByEmail(contact, password)
+		userData, err = a.UserModel.AuthenticateByEmail(ctx, contact, password)
 	} else {
-		userData, err = a.UserModel.AuthenticateByUsername(contact, password)
+		userData, err = a.UserModel.AuthenticateByUsername(ctx, contact, password)
 	}
 
 	return userData, err
 }
 
-func (a *AuthService) RegisterUser(username, email, password string) error {
-	return a.UserModel.CreateUser(username, email, password)
+func (a *AuthService) RegisterUser(ctx context.Context, username, email, password string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RegisterUser")
+	defer span.End()
+
+	return a.UserModel.CreateUser(ctx, username, email, password)
 }
 
 func (a *AuthService) GetTokenExpiration() time.Duration {
-	return appConstants.Jwt_Expiration
+	return a.TokenExpiration
+}
+
+func init() {
+	// iat keeps its milliseconds, so that a logout everywhere can tell the
+	// tokens issued just before it from those of a login just after it
+	jwt.TimePrecision = time.Millisecond
 }
 
-func (a *AuthService) GenerateAuthToken(userID int, username, email string) (string, error) {
-	expirationTime := time.Now().Add(appConstants.Jwt_Expiration)
+func (a *AuthService) GenerateAuthToken(userID int, username, email string, role models.Role) (string, error) {
+	expirationTime := time.Now().Add(a.TokenExpiration)
+
+	// The jti identifies the token in the denylist once it is revoked
+	jti, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", err
+	}
 
+	now := time.Now()
 	claims := &UserClaims{
 		ID:       userID,
 		Username: username,
 		Email:    email,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(a.JWTSecret)
+	var signedToken string
+	if a.Keys != nil {
+		// The kid tells verifiers, this server included, which key to use
+		key := a.Keys.SigningKey()
+		token := jwt.NewWithClaims(key.Method(), claims)
+		token.Header["kid"] = key.ID
+		signedToken, err = token.SignedString(key.Private)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedToken, err = token.SignedString(a.JWTSecret)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -82,6 +142,159 @@ func (a *AuthService) GenerateAuthToken(userID int, username, email string) (str
 	return signedToken, nil
 }
 
+// ParseAuthToken verifies the signature and lifetime of a JWT and returns its
+// claims. Errors match the jwt package's, such as jwt.ErrTokenExpired.
+func (a *AuthService) ParseAuthToken(tokenString string) (*UserClaims, error) {
+	claims := &UserClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, a.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// verificationKey returns the key that checks the signature of token. Only
+// the configured algorithm is accepted, so that an HS256 token cannot be
+// verified with a public key used as an HMAC secret.
+func (a *AuthService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if a.Keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return a.JWTSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := a.Keys.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Method().Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public(), nil
+}
+
+// IsAuthTokenRevoked reports whether the token was revoked by a logout, or
+// by a logout everywhere of its user.
+func (a *AuthService) IsAuthTokenRevoked(ctx context.Context, claims *UserClaims) (bool, error) {
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	return a.Denylist.IsRevoked(ctx, claims.RegisteredClaims.ID, claims.ID, issuedAt)
+}
+
+// IsSessionRevoked reports whether a session in which the user logged in at
+// loggedInAt was revoked by a logout everywhere.
+func (a *AuthService) IsSessionRevoked(ctx context.Context, userID int, loggedInAt time.Time) (bool, error) {
+	return a.Denylist.IsRevoked(ctx, "", userID, loggedInAt)
+}
+
+// RevokeAuthToken refuses the token from now until it expires.
+func (a *AuthService) RevokeAuthToken(ctx context.Context, claims *UserClaims) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeAuthToken")
+	defer span.End()
+
+	// Tokens issued before jti existed cannot be listed; they expire soon
+	if claims.RegisteredClaims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return a.Denylist.Revoke(ctx, claims.RegisteredClaims.ID, claims.ExpiresAt.Time)
+}
+
+// RevokeRefreshToken revokes the user's refresh token and every token rotated
+// from the same login, so the client cannot get new JWTs with it.
+func (a *AuthService) RevokeRefreshToken(ctx context.Context, userID int, refreshToken string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeRefreshToken")
+	defer span.End()
+
+	return a.RefreshTokenModel.RevokeRefreshTokenFamily(ctx, userID, hashToken(refreshToken))
+}
+
+// LogoutEverywhere revokes every JWT, session and refresh token the user has
+// been given so far, on every device.
+func (a *AuthService) LogoutEverywhere(ctx context.Context, userID int) error {
+	ctx, span := tracer.Start(ctx, "AuthService.LogoutEverywhere")
+	defer span.End()
+
+	if err := a.Denylist.RevokeUser(ctx, userID, time.Now()); err != nil {
+		return err
+	}
+	return a.RefreshTokenModel.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// IssueRefreshToken starts a new token family for the user, one per login,
+// and returns its first refresh token.
+func (a *AuthService) IssueRefreshToken(ctx context.Context, userID int) (string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IssueRefreshToken")
+	defer span.End()
+
+	familyID := make([]byte, 16)
+	if _, err := rand.Read(familyID); err != nil {
+		return "", fmt.Errorf("failed to generate token family: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(a.RefreshTokenExpiration)
+	err = a.RefreshTokenModel.CreateRefreshToken(ctx, userID, hex.EncodeToString(familyID), hashToken(refreshToken), expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// RefreshAuthToken trades a refresh token for a new JWT and the next refresh
+// token of its family. The old refresh token cannot be used again: doing so
+// fails with models.ErrRefreshTokenReused and revokes the whole family.
+func (a *AuthService) RefreshAuthToken(ctx context.Context, refreshToken string) (*AuthData, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RefreshAuthToken")
+	defer span.End()
+
+	newRefreshToken, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(a.RefreshTokenExpiration)
+	rotated, err := a.RefreshTokenModel.RotateRefreshToken(ctx, hashToken(refreshToken), hashToken(newRefreshToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	userData, err := a.UserModel.GetUserByID(ctx, rotated.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := a.GenerateAuthToken(userData.ID, userData.Username, userData.Email, userData.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.CreateAuthData(*userData, token)
+	authData.RefreshToken = newRefreshToken
+	return authData, nil
+}
+
+// hashToken returns the form refresh and password reset tokens are stored in.
+// The tokens are random, so a fast unsalted hash is enough to make a leaked
+// table useless.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (a *AuthService) CreateAuthData(userData models.UserData, token string) *AuthData {
 	return &AuthData{
 		User:  userData,
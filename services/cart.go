@@ -1,35 +1,87 @@
 package services
 
 import (
+	"context"
+
+	appErrors "dessert-ordering-go-system/internal/app_errors"
 	"dessert-ordering-go-system/models"
 )
 
 type CartItemService struct {
-	CartItemModel *models.CartItemModel
+	CartItemModel models.CartRepository
+	OrderModel    models.OrderRepository
+	UserModel     models.UserRepository
 }
 
-func NewCartItemService(cartItemModel *models.CartItemModel) *CartItemService {
+func NewCartItemService(cartItemModel models.CartRepository, orderModel models.OrderRepository, userModel models.UserRepository) *CartItemService {
 	return &CartItemService{
 		CartItemModel: cartItemModel,
+		OrderModel:    orderModel,
+		UserModel:     userModel,
 	}
 }
 
-func (ci *CartItemService) GetCart(userID int) (models.Cart, error) {
-	return ci.CartItemModel.GetCartItems(userID)
+func (ci *CartItemService) GetCart(ctx context.Context, userID int) (models.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartItemService.GetCart")
+	defer span.End()
+
+	return ci.CartItemModel.GetCartItems(ctx, userID)
 }
 
-func (ci *CartItemService) AddCartItem(userID, productID int) error {
-	return ci.CartItemModel.AddCartItem(userID, productID, 1)
+// AddCartItem adds one unit of the product to the cart. It fails with an error
+// matching models.ErrInsufficientStock when the product has run out.
+func (ci *CartItemService) AddCartItem(ctx context.Context, userID, productID int) error {
+	ctx, span := tracer.Start(ctx, "CartItemService.AddCartItem")
+	defer span.End()
+
+	err := ci.CartItemModel.AddCartItem(ctx, userID, productID, 1)
+	if err == models.ErrProductNotFound {
+		return &appErrors.NotFoundError{Message: err.Error(), Code: 404}
+	}
+	return err
+}
+
+// SetCartItemQuantity sets the quantity of a product in the cart. A quantity of 0
+// removes the product from the cart.
+func (ci *CartItemService) SetCartItemQuantity(ctx context.Context, userID, productID, quantity int) error {
+	ctx, span := tracer.Start(ctx, "CartItemService.SetCartItemQuantity")
+	defer span.End()
+
+	err := ci.CartItemModel.SetCartItemQuantity(ctx, userID, productID, quantity)
+	if err == models.ErrProductNotFound {
+		return &appErrors.NotFoundError{Message: err.Error(), Code: 404}
+	}
+	return err
 }
 
-func (ci *CartItemService) RemoveSingleCartItem(userID, productID int) error {
-	return ci.CartItemModel.RemoveSingleCartItem(userID, productID)
+func (ci *CartItemService) RemoveSingleCartItem(ctx context.Context, userID, productID int) error {
+	ctx, span := tracer.Start(ctx, "CartItemService.RemoveSingleCartItem")
+	defer span.End()
+
+	return ci.CartItemModel.RemoveSingleCartItem(ctx, userID, productID)
 }
 
-func (ci *CartItemService) RemoveCartItem(userID, cartItemID int) error {
-	return ci.CartItemModel.RemoveCartItem(userID, cartItemID)
+func (ci *CartItemService) RemoveCartItem(ctx context.Context, userID, cartItemID int) error {
+	ctx, span := tracer.Start(ctx, "CartItemService.RemoveCartItem")
+	defer span.End()
+
+	return ci.CartItemModel.RemoveCartItem(ctx, userID, cartItemID)
 }
 
-func (ci *CartItemService) Checkout(userID int) error {
-	return ci.CartItemModel.ClearCart(userID)
+// Checkout places an order from the user's cart. The cart is emptied and the stock
+// reduced in the same transaction that records the order. Users whose email is
+// not verified get models.ErrEmailNotVerified.
+func (ci *CartItemService) Checkout(ctx context.Context, userID int) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "CartItemService.Checkout")
+	defer span.End()
+
+	user, err := ci.UserModel.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.VerifiedAt == nil {
+		return nil, models.ErrEmailNotVerified
+	}
+
+	return ci.OrderModel.CreateOrderFromCart(ctx, userID)
 }
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	mailer "dessert-ordering-go-system/internal/mailer"
+	utils "dessert-ordering-go-system/internal/utils"
+	models "dessert-ordering-go-system/models"
+)
+
+var (
+	ErrEmailAlreadyVerified = errors.New("your email address is already verified")
+	ErrVerificationCooldown = errors.New("a verification email was sent recently")
+)
+
+// VerificationCooldownError is returned when a verification email is asked
+// for again too soon. It matches ErrVerificationCooldown with errors.Is.
+type VerificationCooldownError struct {
+	Wait time.Duration
+}
+
+func (e *VerificationCooldownError) Error() string {
+	return fmt.Sprintf("%s, please wait %s before asking for another", ErrVerificationCooldown, e.Wait.Round(time.Second))
+}
+
+func (e *VerificationCooldownError) Is(target error) bool {
+	return target == ErrVerificationCooldown
+}
+
+type EmailVerificationService struct {
+	UserModel              models.UserRepository
+	EmailVerificationModel models.EmailVerificationRepository
+	Mailer                 mailer.Mailer
+	// BaseURL is where the link in the email points, without a trailing slash
+	BaseURL         string
+	TokenExpiration time.Duration
+	ResendCooldown  time.Duration
+}
+
+func NewEmailVerificationService(userModel models.UserRepository, emailVerificationModel models.EmailVerificationRepository, m mailer.Mailer, baseURL string, tokenExpiration, resendCooldown time.Duration) *EmailVerificationService {
+	return &EmailVerificationService{
+		UserModel:              userModel,
+		EmailVerificationModel: emailVerificationModel,
+		Mailer:                 m,
+		BaseURL:                baseURL,
+		TokenExpiration:        tokenExpiration,
+		ResendCooldown:         resendCooldown,
+	}
+}
+
+// SendVerificationEmail emails a verification link to the user who just
+// registered with the email.
+func (s *EmailVerificationService) SendVerificationEmail(ctx context.Context, email string) error {
+	ctx, span := tracer.Start(ctx, "EmailVerificationService.SendVerificationEmail")
+	defer span.End()
+
+	user, err := s.UserModel.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, user)
+}
+
+// ResendVerificationEmail emails a new verification link to the user. It
+// returns ErrEmailAlreadyVerified once the email is verified, and a
+// *VerificationCooldownError when the last link was sent too recently.
+func (s *EmailVerificationService) ResendVerificationEmail(ctx context.Context, userID int) error {
+	ctx, span := tracer.Start(ctx, "EmailVerificationService.ResendVerificationEmail")
+	defer span.End()
+
+	// 1. Make sure there is something left to verify
+	user, err := s.UserModel.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.VerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	// 2. Keep the button from flooding the user's inbox
+	sentAt, err := s.EmailVerificationModel.LastEmailVerificationSentAt(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if wait := s.ResendCooldown - time.Since(sentAt); wait > 0 {
+		return &VerificationCooldownError{Wait: wait}
+	}
+
+	return s.send(ctx, user)
+}
+
+func (s *EmailVerificationService) send(ctx context.Context, user *models.UserData) error {
+	// Store the hash of a new token, the token itself only goes in the email
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate email verification token: %w", err)
+	}
+	err = s.EmailVerificationModel.CreateEmailVerification(ctx, user.ID, hashToken(token), time.Now().Add(s.TokenExpiration))
+	if err != nil {
+		return err
+	}
+
+	link := s.BaseURL + "/verify-email?token=" + url.QueryEscape(token)
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Dessert Ordering email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to verify your email address:\n\n%s\n\n"+
+			"The link works for %s. You can place orders once your address is verified.\n",
+			user.Username, link, s.TokenExpiration),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the link's user as verified and returns
+// their ID. It returns models.ErrInvalidEmailVerification when the link is
+// unknown or expired.
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) (int, error) {
+	ctx, span := tracer.Start(ctx, "EmailVerificationService.VerifyEmail")
+	defer span.End()
+
+	return s.EmailVerificationModel.VerifyEmail(ctx, hashToken(token))
+}
+
+func (s *EmailVerificationService) IsEmailVerified(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracer.Start(ctx, "EmailVerificationService.IsEmailVerified")
+	defer span.End()
+
+	user, err := s.UserModel.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.VerifiedAt != nil, nil
+}
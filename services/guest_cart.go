@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	"dessert-ordering-go-system/models"
+)
+
+// GuestCartService works on the session cart of anonymous visitors. Its methods
+// change the given cart in place; saving it back to the session is up to the caller.
+type GuestCartService struct {
+	CartItemModel models.CartRepository
+	ProductModel  models.ProductRepository
+}
+
+func NewGuestCartService(cartItemModel models.CartRepository, productModel models.ProductRepository) *GuestCartService {
+	return &GuestCartService{
+		CartItemModel: cartItemModel,
+		ProductModel:  productModel,
+	}
+}
+
+func (gc *GuestCartService) GetCart(ctx context.Context, cart models.GuestCart) (models.Cart, error) {
+	ctx, span := tracer.Start(ctx, "GuestCartService.GetCart")
+	defer span.End()
+
+	return gc.CartItemModel.GetGuestCartItems(ctx, cart)
+}
+
+// checkStock makes sure the product can be sold in the given quantity.
+func (gc *GuestCartService) checkStock(ctx context.Context, productID, quantity int) error {
+	product, err := gc.ProductModel.GetProductByID(ctx, productID)
+	if err == models.ErrProductNotFound || (err == nil && product.IsArchived()) {
+		return &appErrors.NotFoundError{Message: models.ErrProductNotFound.Error(), Code: 404}
+	} else if err != nil {
+		return err
+	}
+
+	if quantity > product.Stock {
+		return &models.InsufficientStockError{ProductID: productID, Title: product.Title, Requested: quantity, Available: product.Stock}
+	}
+	return nil
+}
+
+func (gc *GuestCartService) AddCartItem(ctx context.Context, cart models.GuestCart, productID int) error {
+	ctx, span := tracer.Start(ctx, "GuestCartService.AddCartItem")
+	defer span.End()
+
+	err := gc.checkStock(ctx, productID, cart[productID]+1)
+	if err != nil {
+		return err
+	}
+
+	cart[productID]++
+	return nil
+}
+
+// SetCartItemQuantity sets the quantity of a product in the cart. A quantity of 0
+// removes the product from the cart.
+func (gc *GuestCartService) SetCartItemQuantity(ctx context.Context, cart models.GuestCart, productID, quantity int) error {
+	ctx, span := tracer.Start(ctx, "GuestCartService.SetCartItemQuantity")
+	defer span.End()
+
+	if quantity < 1 {
+		delete(cart, productID)
+		return nil
+	}
+
+	err := gc.checkStock(ctx, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	cart[productID] = quantity
+	return nil
+}
+
+func (gc *GuestCartService) RemoveSingleCartItem(cart models.GuestCart, productID int) error {
+	if _, ok := cart[productID]; !ok {
+		return &appErrors.NotFoundError{Message: models.ErrCartItemNotFound.Error(), Code: 404}
+	}
+
+	cart[productID]--
+	if cart[productID] < 1 {
+		delete(cart, productID)
+	}
+	return nil
+}
+
+// RemoveCartItem removes a product from the cart. Guest cart items are
+// identified by their product ID.
+func (gc *GuestCartService) RemoveCartItem(cart models.GuestCart, productID int) error {
+	if _, ok := cart[productID]; !ok {
+		return &appErrors.NotFoundError{Message: models.ErrCartItemNotFound.Error(), Code: 404}
+	}
+
+	delete(cart, productID)
+	return nil
+}
+
+// MergeIntoUserCart moves the guest cart into the cart of a user who has just
+// logged in. It returns the lines that were reduced to what can be ordered.
+func (gc *GuestCartService) MergeIntoUserCart(ctx context.Context, userID int, cart models.GuestCart) ([]*models.ReducedCartItem, error) {
+	ctx, span := tracer.Start(ctx, "GuestCartService.MergeIntoUserCart")
+	defer span.End()
+
+	if len(cart) == 0 {
+		return nil, nil
+	}
+	return gc.CartItemModel.MergeGuestCart(ctx, userID, cart)
+}
@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
+	money "dessert-ordering-go-system/internal/money"
 	utils "dessert-ordering-go-system/internal/utils"
 	"dessert-ordering-go-system/models"
 )
@@ -30,6 +32,9 @@ type HomeTemplateData struct {
 	TotalCartPrice    string
 	TotalCartQuantity int
 	UserID            int
+	GuestCart         models.GuestCart
+	// IsEmailUnverified shows the logged in user how to verify their email
+	IsEmailUnverified bool
 }
 
 func (c HomeTemplateData) String() string {
@@ -44,13 +49,13 @@ func (c HomeTemplateData) String() string {
 }
 
 type HomeTemplateDataService struct {
-	CartItemModel *models.CartItemModel
-	ProductModel  *models.ProductModel
+	CartItemModel models.CartRepository
+	ProductModel  models.ProductRepository
 }
 
 type GetHomeTemplateContentOptionsFunc func(*HomeTemplateData)
 
-func NewHomeTemplateDataService(cm *models.CartItemModel, pm *models.ProductModel) *HomeTemplateDataService {
+func NewHomeTemplateDataService(cm models.CartRepository, pm models.ProductRepository) *HomeTemplateDataService {
 	return &HomeTemplateDataService{
 		CartItemModel: cm,
 		ProductModel:  pm,
@@ -69,13 +74,23 @@ func (s *HomeTemplateDataService) WithUserID(userID int) GetHomeTemplateContentO
 	}
 }
 
+// WithGuestCart sets the session cart shown to anonymous visitors. It is ignored
+// once a user ID is set.
+func (s *HomeTemplateDataService) WithGuestCart(cart models.GuestCart) GetHomeTemplateContentOptionsFunc {
+	return func(opts *HomeTemplateData) {
+		opts.GuestCart = cart
+	}
+}
+
 func (s *HomeTemplateDataService) WithErrors(errs []string) GetHomeTemplateContentOptionsFunc {
 	return func(opts *HomeTemplateData) {
 		opts.Errors = append(opts.Errors, errs...)
 	}
 }
 
-func (s *HomeTemplateDataService) GetHomeTemplateContent(opts ...GetHomeTemplateContentOptionsFunc) (*HomeTemplateData, error) {
+func (s *HomeTemplateDataService) GetHomeTemplateContent(ctx context.Context, opts ...GetHomeTemplateContentOptionsFunc) (*HomeTemplateData, error) {
+	ctx, span := tracer.Start(ctx, "HomeTemplateDataService.GetHomeTemplateContent")
+	defer span.End()
 
 	var templateContent *HomeTemplateData = &HomeTemplateData{}
 
@@ -84,15 +99,20 @@ func (s *HomeTemplateDataService) GetHomeTemplateContent(opts ...GetHomeTemplate
 	}
 
 	userID := templateContent.UserID
-	products, err := s.ProductModel.GetAllProducts()
+	products, err := s.ProductModel.GetAllProducts(ctx)
 	if err != nil {
-		log.Printf("ERROR: HomeTemplateDataService.GetHomeTemplateContent - Failed to get all products: %v", err)
+		slog.ErrorContext(ctx, "HomeTemplateDataService.GetHomeTemplateContent - Failed to get all products", "error", err)
 		return nil, fmt.Errorf("failed to load product catalog: %w", err)
 	}
 
-	cart, err := s.CartItemModel.GetCartItems(userID)
+	var cart models.Cart
+	if userID > 0 {
+		cart, err = s.CartItemModel.GetCartItems(ctx, userID)
+	} else {
+		cart, err = s.CartItemModel.GetGuestCartItems(ctx, templateContent.GuestCart)
+	}
 	if err != nil {
-		log.Printf("ERROR: HomeTemplateDataService.GetHomeTemplateContent - Failed to get user cart items for user %d: %v", userID, err)
+		slog.ErrorContext(ctx, "HomeTemplateDataService.GetHomeTemplateContent - Failed to get user cart items", "error", err)
 		return nil, fmt.Errorf("failed to load user cart: %w", err)
 	}
 
@@ -103,23 +123,34 @@ func (s *HomeTemplateDataService) GetHomeTemplateContent(opts ...GetHomeTemplate
 
 	cartQuantities := make(map[int]int)
 
-	totalCartPrice := 0.0 // Initialize total cart price
+	// Cart lines reuse the catalog loaded above, so a render costs the same
+	// number of queries whatever the size of the cart
+	displayProducts := models.NewProductsForDisplay(products)
+	displayProductsByID := make(map[int]*models.ProductForDisplay, len(displayProducts))
+	for _, product := range displayProducts {
+		displayProductsByID[product.ID] = product
+	}
+
+	totalCartPrice := money.Zero(money.DefaultCurrency) // Initialize total cart price
 	totalCartQuantity := 0
 
 	for _, cartItem := range cart {
 		cartQuantities[cartItem.ProductID] = cartItem.Quantity
 
-		product, err := s.ProductModel.GetProductByID(cartItem.ProductID)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("could not retrieve product with ID: %v, %v", cartItem.ProductID, err))
+		// Archived products are not in the catalog, but the cart query has
+		// already joined what the cart shows of them
+		if cartItem.Product == nil || cartItem.Product.ID == 0 {
+			errors = append(errors, fmt.Sprintf("could not retrieve product with ID: %v, %v", cartItem.ProductID, models.ErrProductNotFound))
 			continue
 		}
+		displayProduct, ok := displayProductsByID[cartItem.ProductID]
+		if !ok {
+			displayProduct = models.NewCartItemProductForDisplay(cartItem.Product)
+		}
 
-		displayProduct := models.NewProductForDisplay(product)
-
-		totalPrice := float64(cartItem.Quantity) * product.Price
+		totalPrice := cartItem.Product.Price.Multiply(cartItem.Quantity)
 
-		totalCartPrice += totalPrice
+		totalCartPrice = totalCartPrice.Add(totalPrice)
 		totalCartQuantity += cartItem.Quantity
 
 		applicationCartItems = append(applicationCartItems, ApplicationCartItem{
@@ -130,7 +161,6 @@ func (s *HomeTemplateDataService) GetHomeTemplateContent(opts ...GetHomeTemplate
 		})
 	}
 
-	displayProducts := models.NewProductsForDisplay(products)
 	for index, product := range displayProducts {
 		quantity := cartQuantities[product.ID]
 
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	money "dessert-ordering-go-system/internal/money"
+	models "dessert-ordering-go-system/models"
+	memory "dessert-ordering-go-system/models/memory"
+	services "dessert-ordering-go-system/services"
+)
+
+// countingProducts and countingCart count the repository calls that reach the
+// database when the SQL models are used.
+type countingProducts struct {
+	models.ProductRepository
+	queries *atomic.Int64
+}
+
+func (r countingProducts) GetAllProducts(ctx context.Context) ([]*models.Product, error) {
+	r.queries.Add(1)
+	return r.ProductRepository.GetAllProducts(ctx)
+}
+
+func (r countingProducts) GetProductByID(ctx context.Context, productID int) (*models.Product, error) {
+	r.queries.Add(1)
+	return r.ProductRepository.GetProductByID(ctx, productID)
+}
+
+type countingCart struct {
+	models.CartRepository
+	queries *atomic.Int64
+}
+
+func (r countingCart) GetCartItems(ctx context.Context, userID int) ([]*models.CartItem, error) {
+	r.queries.Add(1)
+	return r.CartRepository.GetCartItems(ctx, userID)
+}
+
+func (r countingCart) GetGuestCartItems(ctx context.Context, cart models.GuestCart) ([]*models.CartItem, error) {
+	r.queries.Add(1)
+	return r.CartRepository.GetGuestCartItems(ctx, cart)
+}
+
+// newCountingHomeService returns a home page service over a cart of cartSize
+// products, and the counter of the queries it makes.
+func newCountingHomeService(tb testing.TB, userID, cartSize int) (*services.HomeTemplateDataService, *atomic.Int64) {
+	tb.Helper()
+
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	for i := range cartSize {
+		product, err := store.CreateProduct(ctx, &models.ProductInput{
+			Title:    fmt.Sprintf("Dessert %d", i),
+			Category: "Cake",
+			Price:    money.New(450, money.DefaultCurrency),
+			Stock:    10,
+		})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if err := store.AddCartItem(ctx, userID, product.ID, 2); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	queries := &atomic.Int64{}
+	service := services.NewHomeTemplateDataService(
+		countingCart{store, queries},
+		countingProducts{store, queries},
+	)
+	return service, queries
+}
+
+// TestGetHomeTemplateContentQueryCount fails if the number of queries per
+// render grows with the size of the cart.
+func TestGetHomeTemplateContentQueryCount(t *testing.T) {
+	const userID = 1
+
+	for _, cartSize := range []int{0, 1, 10, 100} {
+		t.Run(fmt.Sprintf("cart=%d", cartSize), func(t *testing.T) {
+			service, queries := newCountingHomeService(t, userID, cartSize)
+
+			data, err := service.GetHomeTemplateContent(context.Background(), service.WithUserID(userID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Cart) != cartSize {
+				t.Fatalf("got %d cart lines, want %d", len(data.Cart), cartSize)
+			}
+			if got := queries.Load(); got != 2 {
+				t.Errorf("a render made %d queries, want 2 (products and cart) whatever the cart size", got)
+			}
+		})
+	}
+}
+
+// BenchmarkGetHomeTemplateContent times the home page data for carts of
+// growing size.
+func BenchmarkGetHomeTemplateContent(b *testing.B) {
+	const userID = 1
+
+	for _, cartSize := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("cart=%d", cartSize), func(b *testing.B) {
+			ctx := context.Background()
+			service, queries := newCountingHomeService(b, userID, cartSize)
+
+			for b.Loop() {
+				if _, err := service.GetHomeTemplateContent(ctx, service.WithUserID(userID)); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+		})
+	}
+}
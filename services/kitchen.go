@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"dessert-ordering-go-system/models"
+)
+
+// KitchenStatuses are the statuses of orders that still need work from staff.
+var KitchenStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusPreparing,
+	models.OrderStatusReady,
+}
+
+// OrderStatusForm is the body of a kitchen status update.
+type OrderStatusForm struct {
+	Status string `json:"status" form:"status" validate:"required,oneof=pending confirmed preparing ready completed cancelled refunded"`
+}
+
+// KitchenService gives staff a view of every open order.
+type KitchenService struct {
+	OrderModel models.OrderRepository
+}
+
+func NewKitchenService(orderModel models.OrderRepository) *KitchenService {
+	return &KitchenService{
+		OrderModel: orderModel,
+	}
+}
+
+// GetQueue returns the open orders, oldest first.
+func (s *KitchenService) GetQueue(ctx context.Context) ([]*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "KitchenService.GetQueue")
+	defer span.End()
+
+	return s.OrderModel.GetOrdersByStatus(ctx, KitchenStatuses)
+}
+
+type KitchenTemplateData struct {
+	CsrfToken string
+	Errors    []string
+	Messages  []string
+	Orders    []*models.OrderForDisplay
+}
+
+func (c KitchenTemplateData) String() string {
+	return fmt.Sprintf("CsrfToken: %v, Errors [%v], Messages [%v], Orders [%v]",
+		c.CsrfToken,
+		len(c.Errors),
+		len(c.Messages),
+		len(c.Orders),
+	)
+}
+
+type KitchenTemplateDataService struct {
+	KitchenService *KitchenService
+}
+
+type GetKitchenTemplateContentOptionsFunc func(*KitchenTemplateData)
+
+func NewKitchenTemplateDataService(kitchenService *KitchenService) *KitchenTemplateDataService {
+	return &KitchenTemplateDataService{
+		KitchenService: kitchenService,
+	}
+}
+
+func (s *KitchenTemplateDataService) WithCsrfToken(csrfToken string) GetKitchenTemplateContentOptionsFunc {
+	return func(opts *KitchenTemplateData) {
+		opts.CsrfToken = csrfToken
+	}
+}
+
+func (s *KitchenTemplateDataService) WithErrors(errs []string) GetKitchenTemplateContentOptionsFunc {
+	return func(opts *KitchenTemplateData) {
+		opts.Errors = append(opts.Errors, errs...)
+	}
+}
+
+// GetKitchenTemplateContent builds the data for the kitchen queue page.
+func (s *KitchenTemplateDataService) GetKitchenTemplateContent(ctx context.Context, opts ...GetKitchenTemplateContentOptionsFunc) (*KitchenTemplateData, error) {
+	ctx, span := tracer.Start(ctx, "KitchenTemplateDataService.GetKitchenTemplateContent")
+	defer span.End()
+
+	var templateContent *KitchenTemplateData = &KitchenTemplateData{}
+
+	for _, fn := range opts {
+		fn(templateContent)
+	}
+
+	orders, err := s.KitchenService.GetQueue(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "KitchenTemplateDataService.GetKitchenTemplateContent - Failed to get kitchen queue", "error", err)
+		return nil, fmt.Errorf("failed to load kitchen queue: %w", err)
+	}
+
+	if templateContent.Errors == nil {
+		templateContent.Errors = []string{}
+	}
+	templateContent.Messages = []string{}
+	templateContent.Orders = models.NewOrdersForDisplay(orders)
+
+	return templateContent, nil
+}
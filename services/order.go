@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	models "dessert-ordering-go-system/models"
+)
+
+const (
+	DefaultOrdersPerPage = 10
+	MaxOrdersPerPage     = 50
+)
+
+type Pagination struct {
+	Page       int  `json:"page"`
+	PerPage    int  `json:"perPage"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"totalPages"`
+	HasPrev    bool `json:"hasPrev"`
+	HasNext    bool `json:"hasNext"`
+	PrevPage   int  `json:"prevPage"`
+	NextPage   int  `json:"nextPage"`
+}
+
+func NewPagination(page, perPage, total int) *Pagination {
+	totalPages := (total + perPage - 1) / perPage
+
+	return &Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+		HasPrev:    page > 1,
+		HasNext:    page < totalPages,
+		PrevPage:   page - 1,
+		NextPage:   page + 1,
+	}
+}
+
+type OrderPage struct {
+	Orders     []*models.Order `json:"orders"`
+	Pagination *Pagination     `json:"pagination"`
+}
+
+type OrderService struct {
+	OrderModel models.OrderRepository
+}
+
+func NewOrderService(orderModel models.OrderRepository) *OrderService {
+	return &OrderService{
+		OrderModel: orderModel,
+	}
+}
+
+// GetOrders returns a page of the user's orders. Out of range page and perPage
+// values are clamped to sensible defaults.
+func (o *OrderService) GetOrders(ctx context.Context, userID, page, perPage int) (*OrderPage, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrders")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultOrdersPerPage
+	} else if perPage > MaxOrdersPerPage {
+		perPage = MaxOrdersPerPage
+	}
+
+	orders, total, err := o.OrderModel.GetOrdersByUser(ctx, userID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderPage{
+		Orders:     orders,
+		Pagination: NewPagination(page, perPage, total),
+	}, nil
+}
+
+func (o *OrderService) GetOrderDetail(ctx context.Context, userID, orderID int) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.GetOrderDetail")
+	defer span.End()
+
+	order, err := o.OrderModel.GetOrderByID(ctx, userID, orderID)
+
+	if err == models.ErrOrderNotFound {
+		return nil, &appErrors.NotFoundError{Message: err.Error(), Code: 404}
+	} else if err != nil {
+		return nil, err
+	}
+
+	order.History, err = o.OrderModel.GetOrderStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
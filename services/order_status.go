@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appErrors "dessert-ordering-go-system/internal/app_errors"
+	models "dessert-ordering-go-system/models"
+)
+
+// OrderStatusService moves orders through their lifecycle. Every change is
+// checked against the allowed transitions and recorded in the status history.
+type OrderStatusService struct {
+	OrderModel models.OrderRepository
+}
+
+func NewOrderStatusService(orderModel models.OrderRepository) *OrderStatusService {
+	return &OrderStatusService{
+		OrderModel: orderModel,
+	}
+}
+
+// UpdateStatus moves the order to the given status on behalf of changedBy.
+// An illegal move returns an error matching models.ErrInvalidStatusTransition.
+func (s *OrderStatusService) UpdateStatus(ctx context.Context, orderID int, to models.OrderStatus, changedBy int) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderStatusService.UpdateStatus")
+	defer span.End()
+
+	return s.updateStatus(ctx, orderID, nil, to, changedBy)
+}
+
+// CancelOrder lets a customer cancel one of their own orders while it is
+// pending or confirmed. Orders of other users are reported as not found.
+func (s *OrderStatusService) CancelOrder(ctx context.Context, userID, orderID int) (*models.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderStatusService.CancelOrder")
+	defer span.End()
+
+	// The owner is checked by the model on the locked order row
+	return s.updateStatus(ctx, orderID, &userID, models.OrderStatusCancelled, userID)
+}
+
+func (s *OrderStatusService) updateStatus(ctx context.Context, orderID int, ownerID *int, to models.OrderStatus, changedBy int) (*models.Order, error) {
+	if !to.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %q", models.ErrInvalidStatusTransition, to)
+	}
+
+	order, err := s.OrderModel.UpdateOrderStatus(ctx, orderID, ownerID, to, &changedBy)
+	if errors.Is(err, models.ErrOrderNotFound) {
+		return nil, &appErrors.NotFoundError{Message: err.Error(), Code: 404}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"dessert-ordering-go-system/models"
+)
+
+type OrdersTemplateData struct {
+	CsrfToken  string
+	Errors     []string
+	Messages   []string
+	Orders     []*models.OrderForDisplay
+	Order      *models.OrderForDisplay
+	Pagination *Pagination
+	Page       int
+	PerPage    int
+	UserID     int
+}
+
+func (c OrdersTemplateData) String() string {
+	return fmt.Sprintf("CsrfToken: %v, Errors [%v], Messages [%v], Orders [%v], Page: %v, UserID: %v",
+		c.CsrfToken,
+		len(c.Errors),
+		len(c.Messages),
+		len(c.Orders),
+		c.Page,
+		c.UserID,
+	)
+}
+
+type OrdersTemplateDataService struct {
+	OrderService *OrderService
+}
+
+type GetOrdersTemplateContentOptionsFunc func(*OrdersTemplateData)
+
+func NewOrdersTemplateDataService(orderService *OrderService) *OrdersTemplateDataService {
+	return &OrdersTemplateDataService{
+		OrderService: orderService,
+	}
+}
+
+func (s *OrdersTemplateDataService) WithCsrfToken(csrfToken string) GetOrdersTemplateContentOptionsFunc {
+	return func(opts *OrdersTemplateData) {
+		opts.CsrfToken = csrfToken
+	}
+}
+
+func (s *OrdersTemplateDataService) WithUserID(userID int) GetOrdersTemplateContentOptionsFunc {
+	return func(opts *OrdersTemplateData) {
+		opts.UserID = userID
+	}
+}
+
+func (s *OrdersTemplateDataService) WithErrors(errs []string) GetOrdersTemplateContentOptionsFunc {
+	return func(opts *OrdersTemplateData) {
+		opts.Errors = append(opts.Errors, errs...)
+	}
+}
+
+func (s *OrdersTemplateDataService) WithPage(page, perPage int) GetOrdersTemplateContentOptionsFunc {
+	return func(opts *OrdersTemplateData) {
+		opts.Page = page
+		opts.PerPage = perPage
+	}
+}
+
+// GetOrdersTemplateContent builds the data for the order history page.
+func (s *OrdersTemplateDataService) GetOrdersTemplateContent(ctx context.Context, opts ...GetOrdersTemplateContentOptionsFunc) (*OrdersTemplateData, error) {
+	ctx, span := tracer.Start(ctx, "OrdersTemplateDataService.GetOrdersTemplateContent")
+	defer span.End()
+
+	var templateContent *OrdersTemplateData = &OrdersTemplateData{}
+
+	for _, fn := range opts {
+		fn(templateContent)
+	}
+
+	userID := templateContent.UserID
+	orderPage, err := s.OrderService.GetOrders(ctx, userID, templateContent.Page, templateContent.PerPage)
+	if err != nil {
+		slog.ErrorContext(ctx, "OrdersTemplateDataService.GetOrdersTemplateContent - Failed to get orders", "error", err)
+		return nil, fmt.Errorf("failed to load order history: %w", err)
+	}
+
+	if templateContent.Errors == nil {
+		templateContent.Errors = []string{}
+	}
+	templateContent.Messages = []string{}
+	templateContent.Orders = models.NewOrdersForDisplay(orderPage.Orders)
+	templateContent.Pagination = orderPage.Pagination
+	templateContent.Page = orderPage.Pagination.Page
+	templateContent.PerPage = orderPage.Pagination.PerPage
+
+	return templateContent, nil
+}
+
+// GetOrderTemplateContent builds the data for a single order page.
+func (s *OrdersTemplateDataService) GetOrderTemplateContent(ctx context.Context, orderID int, opts ...GetOrdersTemplateContentOptionsFunc) (*OrdersTemplateData, error) {
+	ctx, span := tracer.Start(ctx, "OrdersTemplateDataService.GetOrderTemplateContent")
+	defer span.End()
+
+	var templateContent *OrdersTemplateData = &OrdersTemplateData{}
+
+	for _, fn := range opts {
+		fn(templateContent)
+	}
+
+	order, err := s.OrderService.GetOrderDetail(ctx, templateContent.UserID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if templateContent.Errors == nil {
+		templateContent.Errors = []string{}
+	}
+	templateContent.Messages = []string{}
+	templateContent.Order = models.NewOrderForDisplay(order)
+
+	return templateContent, nil
+}
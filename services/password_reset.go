@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	mailer "dessert-ordering-go-system/internal/mailer"
+	utils "dessert-ordering-go-system/internal/utils"
+	models "dessert-ordering-go-system/models"
+)
+
+// PasswordResetRequestedMessage is shown whether or not an account has the
+// email, so that the form does not tell strangers who is registered.
+const PasswordResetRequestedMessage = "If an account uses this email, a link to reset its password is on its way"
+
+type ForgotPasswordForm struct {
+	Email string `json:"email" form:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordForm struct {
+	Token    string `json:"token" form:"token" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required,min=6,max=255"`
+}
+
+// PasswordResetTemplateData fills both forgot_password.html and
+// reset_password.html.
+type PasswordResetTemplateData struct {
+	CsrfToken string
+	Errors    []string
+	Messages  []string
+	Token     string
+}
+
+type PasswordResetService struct {
+	UserModel          models.UserRepository
+	PasswordResetModel models.PasswordResetRepository
+	Mailer             mailer.Mailer
+	// BaseURL is where the link in the email points, without a trailing slash
+	BaseURL         string
+	TokenExpiration time.Duration
+	// Cooldown is how long after a link another one can be sent to the same user
+	Cooldown time.Duration
+}
+
+func NewPasswordResetService(userModel models.UserRepository, passwordResetModel models.PasswordResetRepository, m mailer.Mailer, baseURL string, tokenExpiration, cooldown time.Duration) *PasswordResetService {
+	return &PasswordResetService{
+		UserModel:          userModel,
+		PasswordResetModel: passwordResetModel,
+		Mailer:             m,
+		BaseURL:            baseURL,
+		TokenExpiration:    tokenExpiration,
+		Cooldown:           cooldown,
+	}
+}
+
+// RequestPasswordReset emails a reset link to the user with the email. It
+// returns nil when there is no such user, or when a link was sent to them less
+// than Cooldown ago, like when the link is sent.
+func (s *PasswordResetService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := tracer.Start(ctx, "PasswordResetService.RequestPasswordReset")
+	defer span.End()
+
+	// 1. Find the user
+	user, err := s.UserModel.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			slog.InfoContext(ctx, "PasswordResetService.RequestPasswordReset - no user with this email")
+			return nil
+		}
+		return err
+	}
+
+	// 2. Keep the form from flooding the user's inbox. Answering like a sent
+	// link keeps the cooldown from telling who is registered.
+	sentAt, err := s.PasswordResetModel.LastPasswordResetSentAt(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if time.Since(sentAt) < s.Cooldown {
+		slog.InfoContext(ctx, "PasswordResetService.RequestPasswordReset - link sent recently, skipping", "user_id", user.ID)
+		return nil
+	}
+
+	// 3. Store the hash of a new token, the token itself only goes in the email
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	err = s.PasswordResetModel.CreatePasswordReset(ctx, user.ID, hashToken(token), time.Now().Add(s.TokenExpiration))
+	if err != nil {
+		return err
+	}
+
+	// 4. Send the link
+	link := s.BaseURL + "/reset-password?token=" + url.QueryEscape(token)
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Dessert Ordering password",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link to choose a new password:\n\n%s\n\n"+
+			"The link works once, for %s. If you did not ask for it, ignore this email and your password stays the same.\n",
+			user.Username, link, s.TokenExpiration),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with the token of a reset link and returns
+// the ID of its user. It returns models.ErrInvalidPasswordReset when the link
+// is unknown, used or expired.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, password string) (int, error) {
+	ctx, span := tracer.Start(ctx, "PasswordResetService.ResetPassword")
+	defer span.End()
+
+	return s.PasswordResetModel.ResetPassword(ctx, hashToken(token), password)
+}
@@ -1,26 +1,99 @@
 package services
 
 import (
+	"context"
+	"errors"
+
 	appErrors "dessert-ordering-go-system/internal/app_errors"
+	money "dessert-ordering-go-system/internal/money"
 	models "dessert-ordering-go-system/models"
 )
 
 type ProductService struct {
-	ProductModel *models.ProductModel
+	ProductModel      models.ProductRepository
+	ProductImageModel models.ProductImageRepository
+}
+
+type ProductForm struct {
+	Title       string `json:"title" form:"title" validate:"required,max=255"`
+	Category    string `json:"category" form:"category" validate:"required,max=255"`
+	Description string `json:"description" form:"description" validate:"max=2000"`
+	Price       string `json:"price" form:"price" validate:"required,numeric"`
+	Thumbnail   string `json:"thumbnail" form:"thumbnail" validate:"required,startswith=/assets/,max=255"`
+	Stock       *int   `json:"stock" form:"stock" validate:"required,min=0"`
+}
+
+// ProductUpdateForm edits a product. ExpectedStock is the stock shown when the
+// form was loaded: only the change from it to Stock is applied, so units
+// reserved by checkouts in the meantime are not put back on sale.
+type ProductUpdateForm struct {
+	ProductForm
+	ExpectedStock *int `json:"expectedStock" form:"expectedStock" validate:"required,min=0"`
+}
+
+type ProductImageForm struct {
+	Image string `json:"image" form:"image" validate:"required,startswith=/assets/,max=255"`
+}
+
+type ProductImageOrderForm struct {
+	ImageIDs []int `json:"imageIds" form:"imageIds" validate:"required,min=1,dive,min=1"`
 }
 
-func NewProductService(productModel *models.ProductModel) *ProductService {
+// AdminProduct is a product as seen from the admin catalog, including archived
+// state and the IDs and positions of its images.
+type AdminProduct struct {
+	*models.Product
+	ProductImages []*models.ProductImage `json:"productImages"`
+}
+
+var ErrInvalidPrice = errors.New("price must be a positive amount with at most two decimal places")
+
+// ToInput converts the submitted form into model input, parsing the price exactly.
+func (f *ProductForm) ToInput() (*models.ProductInput, error) {
+	price, err := money.Parse(f.Price, money.DefaultCurrency)
+	if err != nil || price.Amount <= 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	return &models.ProductInput{
+		Title:       f.Title,
+		Category:    f.Category,
+		Description: f.Description,
+		Price:       price,
+		Thumbnail:   f.Thumbnail,
+		Stock:       *f.Stock,
+	}, nil
+}
+
+// ToInput converts the submitted form into model input for UpdateProduct.
+func (f *ProductUpdateForm) ToInput() (*models.ProductInput, error) {
+	input, err := f.ProductForm.ToInput()
+	if err != nil {
+		return nil, err
+	}
+	input.StockChange = *f.Stock - *f.ExpectedStock
+	return input, nil
+}
+
+func NewProductService(productModel models.ProductRepository, productImageModel models.ProductImageRepository) *ProductService {
 	return &ProductService{
-		ProductModel: productModel,
+		ProductModel:      productModel,
+		ProductImageModel: productImageModel,
 	}
 }
 
-func (ps *ProductService) GetAllProducts() ([]*models.Product, error) {
-	return ps.ProductModel.GetAllProducts()
+func (ps *ProductService) GetAllProducts(ctx context.Context) ([]*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAllProducts")
+	defer span.End()
+
+	return ps.ProductModel.GetAllProducts(ctx)
 }
 
-func (ps *ProductService) GetProductDetail(productID int) (*models.Product, error) {
-	product, err := ps.ProductModel.GetProductByID(productID)
+func (ps *ProductService) GetProductDetail(ctx context.Context, productID int) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetProductDetail")
+	defer span.End()
+
+	product, err := ps.ProductModel.GetProductByID(ctx, productID)
 
 	if err == models.ErrProductNotFound || product == nil {
 		return nil, &appErrors.NotFoundError{Message: err.Error(), Code: 404}
@@ -30,3 +103,110 @@ func (ps *ProductService) GetProductDetail(productID int) (*models.Product, erro
 
 	return product, nil
 }
+
+// ****** Admin Catalog *******
+
+// GetAdminProducts returns every product, archived ones included, with their images.
+func (ps *ProductService) GetAdminProducts(ctx context.Context) ([]*AdminProduct, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAdminProducts")
+	defer span.End()
+
+	products, err := ps.ProductModel.GetAllProductsIncludingArchived(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := ps.ProductImageModel.GetAllProductImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adminProducts := make([]*AdminProduct, len(products))
+	for i, product := range products {
+		productImages := images[product.ID]
+		if productImages == nil {
+			productImages = []*models.ProductImage{}
+		}
+		adminProducts[i] = &AdminProduct{Product: product, ProductImages: productImages}
+	}
+	return adminProducts, nil
+}
+
+func (ps *ProductService) GetAdminProduct(ctx context.Context, productID int) (*AdminProduct, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAdminProduct")
+	defer span.End()
+
+	product, err := ps.GetProductDetail(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := ps.ProductImageModel.GetProductImages(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdminProduct{Product: product, ProductImages: images}, nil
+}
+
+func (ps *ProductService) CreateProduct(ctx context.Context, input *models.ProductInput) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.CreateProduct")
+	defer span.End()
+
+	return ps.ProductModel.CreateProduct(ctx, input)
+}
+
+func (ps *ProductService) UpdateProduct(ctx context.Context, productID int, input *models.ProductInput) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateProduct")
+	defer span.End()
+
+	product, err := ps.ProductModel.UpdateProduct(ctx, productID, input)
+	return product, toProductNotFoundError(err)
+}
+
+func (ps *ProductService) ArchiveProduct(ctx context.Context, productID int) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.ArchiveProduct")
+	defer span.End()
+
+	product, err := ps.ProductModel.SetProductArchived(ctx, productID, true)
+	return product, toProductNotFoundError(err)
+}
+
+func (ps *ProductService) RestoreProduct(ctx context.Context, productID int) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.RestoreProduct")
+	defer span.End()
+
+	product, err := ps.ProductModel.SetProductArchived(ctx, productID, false)
+	return product, toProductNotFoundError(err)
+}
+
+func (ps *ProductService) AddProductImage(ctx context.Context, productID int, image string) (*models.ProductImage, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.AddProductImage")
+	defer span.End()
+
+	productImage, err := ps.ProductImageModel.AddProductImage(ctx, productID, image)
+	return productImage, toProductNotFoundError(err)
+}
+
+func (ps *ProductService) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) ([]*models.ProductImage, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.ReorderProductImages")
+	defer span.End()
+
+	return ps.ProductImageModel.ReorderProductImages(ctx, productID, imageIDs)
+}
+
+func (ps *ProductService) RemoveProductImage(ctx context.Context, productID, imageID int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.RemoveProductImage")
+	defer span.End()
+
+	return toProductNotFoundError(ps.ProductImageModel.RemoveProductImage(ctx, productID, imageID))
+}
+
+// toProductNotFoundError maps the catalog's not found errors onto NotFoundError
+// so handlers can answer with a 404.
+func toProductNotFoundError(err error) error {
+	if err == models.ErrProductNotFound || err == models.ErrProductImageNotFound {
+		return &appErrors.NotFoundError{Message: err.Error(), Code: 404}
+	}
+	return err
+}
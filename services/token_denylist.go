@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// TokenDenylist remembers revoked JWTs until they would have expired anyway.
+type TokenDenylist interface {
+	// Revoke refuses the token with the jti until expiresAt.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUser refuses every token and session of the user issued before
+	// the given time, to the millisecond.
+	RevokeUser(ctx context.Context, userID int, before time.Time) error
+	// IsRevoked reports whether a token, or a session when jti is empty, of
+	// the user issued at issuedAt has been revoked.
+	IsRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error)
+}
+
+type userClaimsKey struct{}
+
+// WithUserClaims returns a copy of ctx carrying the claims of the JWT that
+// authenticated the request.
+func WithUserClaims(ctx context.Context, claims *UserClaims) context.Context {
+	return context.WithValue(ctx, userClaimsKey{}, claims)
+}
+
+// UserClaimsFromContext returns the claims stored by WithUserClaims, or nil
+// when the request was authenticated by its session.
+func UserClaimsFromContext(ctx context.Context) *UserClaims {
+	claims, _ := ctx.Value(userClaimsKey{}).(*UserClaims)
+	return claims
+}
@@ -0,0 +1,6 @@
+package services
+
+import "go.opentelemetry.io/otel"
+
+// tracer records a span for every service call, as a child of the request's span.
+var tracer = otel.Tracer("dessert-ordering-go-system/services")